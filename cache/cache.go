@@ -0,0 +1,249 @@
+// Package cache 基于mgredis组中命名客户端的类型化旁路缓存
+//
+// Cache[T]负责值的编解码和过期时间，GetOrLoad在缓存未命中时调用loader加载并回填，
+// 同一进程内对同一键的并发加载只执行一次。loader返回ErrNotFound时可缓存空结果，避免缓存穿透；
+// 过期时间带有随机抖动，避免大量键同时过期。
+//
+// Near为热点键提供进程内的本地缓存，通过CLIENT TRACKING或广播频道的失效通知与Redis保持一致，
+// 可单独使用，也可以通过WithNear作为Cache的一级缓存。
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultTTL 缓存值的默认过期时间
+	DefaultTTL = 10 * time.Minute
+
+	// DefaultJitter 过期时间的默认抖动比例
+	DefaultJitter = 0.1
+)
+
+// ErrNotFound 缓存中不存在该键，或键被缓存为空结果
+// loader返回该错误表示数据源中不存在该值，启用空结果缓存时会被缓存
+var ErrNotFound = errors.New("mgredis/cache: not found")
+
+// IsErrNotFound 判断是否为不存在错误
+func IsErrNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// 缓存值的首字节标记值的类型，之后为编码后的值
+const (
+	markerValue    byte = 1
+	markerNotFound byte = 0
+)
+
+// Option 缓存选项
+type Option func(*options)
+
+type options struct {
+	codec       Codec
+	prefix      string
+	ttl         time.Duration
+	jitter      float64
+	negativeTTL time.Duration
+	near        *Near
+}
+
+// WithCodec 设置编解码器，默认为JSON
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithKeyPrefix 设置缓存键的前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTTL 设置缓存值的过期时间
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithJitter 设置过期时间的抖动比例，实际过期时间在 TTL*(1±jitter) 内随机，0表示不抖动
+func WithJitter(jitter float64) Option {
+	return func(o *options) {
+		if jitter >= 0 && jitter < 1 {
+			o.jitter = jitter
+		}
+	}
+}
+
+// WithNegativeTTL 设置空结果的缓存时间，loader返回ErrNotFound时缓存空结果，默认为0表示不缓存
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithNear 使用本地缓存作为一级缓存，near应基于与Cache相同的客户端创建
+func WithNear(near *Near) Option {
+	return func(o *options) {
+		o.near = near
+	}
+}
+
+// Loader 缓存未命中时从数据源加载值
+type Loader[T any] func(ctx context.Context, key string) (T, error)
+
+// Cache 类型化的旁路缓存
+//
+// 每次操作都通过Group.Get获取客户端，客户端惰性创建，Reconfigure后自动使用新客户端。
+type Cache[T any] struct {
+	group  mgredis.Group
+	name   string
+	opts   options
+	flight singleflight.Group
+}
+
+// New 创建缓存，name为组中已注册的客户端名称
+func New[T any](group mgredis.Group, name string, opts ...Option) *Cache[T] {
+	o := options{
+		codec:  JSON,
+		ttl:    DefaultTTL,
+		jitter: DefaultJitter,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Cache[T]{group: group, name: name, opts: o}
+}
+
+// Get 读取缓存，不存在或被缓存为空结果时返回ErrNotFound
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	value, _, err := c.lookup(ctx, key)
+	return value, err
+}
+
+// Set 写入缓存，过期时间为带抖动的TTL
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	data, err := c.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.set(ctx, key, append([]byte{markerValue}, data...), c.opts.ttl)
+}
+
+// Delete 删除缓存
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.opts.prefix + key
+	}
+	if c.opts.near != nil {
+		return c.opts.near.Delete(ctx, prefixed...)
+	}
+	client, err := c.group.Get(ctx, c.name)
+	if err != nil {
+		return err
+	}
+	return client.Del(ctx, prefixed...).Err()
+}
+
+// GetOrLoad 读取缓存，未命中时调用loader加载并回填
+//
+// 同一键的并发调用只执行一次loader，loader使用首个调用者的ctx且不随其取消而取消。
+// loader返回ErrNotFound时按WithNegativeTTL缓存空结果并返回ErrNotFound，其他错误不缓存。
+// Redis不可用或缓存值无法解码时直接调用loader，回填失败不影响返回结果。
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	value, negative, err := c.lookup(ctx, key)
+	if err == nil || negative || mgredis.IsErrClientNotFound(err) {
+		return value, err
+	}
+
+	ch := c.flight.DoChan(key, func() (interface{}, error) {
+		loadCtx := context.WithoutCancel(ctx)
+		value, err := loader(loadCtx, key)
+		switch {
+		case err == nil:
+			_ = c.Set(loadCtx, key, value)
+		case IsErrNotFound(err) && c.opts.negativeTTL > 0:
+			_ = c.set(loadCtx, key, []byte{markerNotFound}, c.opts.negativeTTL)
+		}
+		return value, err
+	})
+
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case res := <-ch:
+		value, _ := res.Val.(T)
+		return value, res.Err
+	}
+}
+
+// lookup 读取并解码缓存值，negative表示键被缓存为空结果
+func (c *Cache[T]) lookup(ctx context.Context, key string) (value T, negative bool, err error) {
+	data, err := c.getRaw(ctx, c.opts.prefix+key)
+	if err != nil {
+		return value, false, err
+	}
+	if len(data) == 0 || data[0] == markerNotFound {
+		return value, true, ErrNotFound
+	}
+	if err := c.opts.codec.Unmarshal(data[1:], &value); err != nil {
+		var zero T
+		return zero, false, err
+	}
+	return value, false, nil
+}
+
+// getRaw 读取原始数据，启用本地缓存时优先读取本地，键不存在时返回ErrNotFound
+func (c *Cache[T]) getRaw(ctx context.Context, key string) ([]byte, error) {
+	if c.opts.near != nil {
+		return c.opts.near.Get(ctx, key)
+	}
+	client, err := c.group.Get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// set 以带抖动的过期时间写入原始数据
+func (c *Cache[T]) set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if c.opts.near != nil {
+		return c.opts.near.Set(ctx, c.opts.prefix+key, data, c.jittered(ttl))
+	}
+	client, err := c.group.Get(ctx, c.name)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, c.opts.prefix+key, data, c.jittered(ttl)).Err()
+}
+
+// jittered 返回在 ttl*(1±jitter) 内随机的过期时间
+func (c *Cache[T]) jittered(ttl time.Duration) time.Duration {
+	if c.opts.jitter <= 0 {
+		return ttl
+	}
+	delta := (rand.Float64()*2 - 1) * c.opts.jitter * float64(ttl)
+	return ttl + time.Duration(delta)
+}
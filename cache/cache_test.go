@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/qq1060656096/mgredis"
+)
+
+type user struct {
+	ID   int64    `json:"id" msgpack:"id"`
+	Name string   `json:"name" msgpack:"name"`
+	Tags []string `json:"tags" msgpack:"tags"`
+}
+
+// newTestGroup 启动miniredis并注册名为cache的客户端
+func newTestGroup(t *testing.T) (*miniredis.Miniredis, mgredis.Group) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	group := mgredis.New()
+	t.Cleanup(func() { group.Close(context.Background()) })
+	_, _ = group.Register(context.Background(), "cache", mgredis.RedisConfig{Addr: s.Addr()})
+	return s, group
+}
+
+// TestCodecs 测试各编解码器的读写
+func TestCodecs(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t)
+	want := user{ID: 1, Name: "tom", Tags: []string{"a", "b"}}
+
+	for name, codec := range map[string]Codec{"json": JSON, "gob": Gob, "msgpack": Msgpack} {
+		c := New[user](group, "cache", WithCodec(codec), WithKeyPrefix(name+":"))
+		if err := c.Set(ctx, "u:1", want); err != nil {
+			t.Fatalf("%s: 写入失败: %v", name, err)
+		}
+		got, err := c.Get(ctx, "u:1")
+		if err != nil || got.ID != want.ID || got.Name != want.Name || len(got.Tags) != 2 {
+			t.Errorf("%s: 读取结果不符: %+v %v", name, got, err)
+		}
+	}
+
+	c := New[user](group, "cache")
+	if _, err := c.Get(ctx, "missing"); !IsErrNotFound(err) {
+		t.Errorf("键不存在时应返回ErrNotFound，实际为: %v", err)
+	}
+	_ = c.Set(ctx, "u:2", want)
+	if err := c.Delete(ctx, "u:2"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if _, err := c.Get(ctx, "u:2"); !IsErrNotFound(err) {
+		t.Errorf("删除后应返回ErrNotFound，实际为: %v", err)
+	}
+}
+
+// TestTTLJitter 测试过期时间的抖动范围
+func TestTTLJitter(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+
+	c := New[int](group, "cache", WithTTL(time.Minute), WithJitter(0.2))
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		_ = c.Set(ctx, "k", i)
+		ttl := s.TTL("k")
+		if ttl < 48*time.Second || ttl > 72*time.Second {
+			t.Fatalf("过期时间应在TTL±20%%内，实际为%v", ttl)
+		}
+		seen[ttl] = true
+	}
+	if len(seen) < 2 {
+		t.Error("过期时间应带有随机抖动")
+	}
+
+	_ = New[int](group, "cache", WithTTL(time.Minute), WithJitter(0)).Set(ctx, "k", 1)
+	if ttl := s.TTL("k"); ttl != time.Minute {
+		t.Errorf("关闭抖动时过期时间应为TTL，实际为%v", ttl)
+	}
+}
+
+// TestGetOrLoad 测试未命中时加载回填，以及并发加载只执行一次
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t)
+	c := New[user](group, "cache", WithCodec(Msgpack))
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (user, error) {
+		calls.Add(1)
+		<-release
+		return user{ID: 7, Name: key}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.GetOrLoad(ctx, "u:7", loader)
+			if err != nil || u.ID != 7 || u.Name != "u:7" {
+				t.Errorf("加载结果不符: %+v %v", u, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("并发加载应只执行一次loader，实际为%d次", n)
+	}
+
+	// 已回填，不再调用loader
+	if _, err := c.GetOrLoad(ctx, "u:7", loader); err != nil || calls.Load() != 1 {
+		t.Errorf("命中缓存时不应调用loader: %v, %d", err, calls.Load())
+	}
+
+	// 调用者ctx取消时返回ctx错误
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	block := func(ctx context.Context, key string) (user, error) {
+		time.Sleep(20 * time.Millisecond)
+		return user{}, nil
+	}
+	if _, err := c.GetOrLoad(cancelCtx, "u:8", block); !errors.Is(err, context.Canceled) {
+		t.Errorf("预期context.Canceled，实际为: %v", err)
+	}
+}
+
+// TestNegativeCache 测试空结果缓存，以及其他错误不缓存
+func TestNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	c := New[user](group, "cache", WithNegativeTTL(30*time.Second), WithJitter(0))
+
+	var calls atomic.Int32
+	notFound := func(ctx context.Context, key string) (user, error) {
+		calls.Add(1)
+		return user{}, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrLoad(ctx, "u:404", notFound); !IsErrNotFound(err) {
+			t.Fatalf("预期ErrNotFound，实际为: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("空结果应被缓存，loader被调用%d次", calls.Load())
+	}
+	if ttl := s.TTL("u:404"); ttl != 30*time.Second {
+		t.Errorf("空结果的过期时间应为30s，实际为%v", ttl)
+	}
+	if _, err := c.Get(ctx, "u:404"); !IsErrNotFound(err) {
+		t.Errorf("Get读取空结果应返回ErrNotFound，实际为: %v", err)
+	}
+
+	failed := errors.New("db down")
+	fail := func(ctx context.Context, key string) (user, error) {
+		calls.Add(1)
+		return user{}, failed
+	}
+	calls.Store(0)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrLoad(ctx, "u:500", fail); !errors.Is(err, failed) {
+			t.Fatalf("应返回loader的错误，实际为: %v", err)
+		}
+	}
+	if calls.Load() != 2 || s.Exists("u:500") {
+		t.Error("loader的其他错误不应被缓存")
+	}
+
+	// 未启用空结果缓存时每次都调用loader
+	plain := New[user](group, "cache", WithKeyPrefix("plain:"))
+	calls.Store(0)
+	_, _ = plain.GetOrLoad(ctx, "u:404", notFound)
+	_, _ = plain.GetOrLoad(ctx, "u:404", notFound)
+	if calls.Load() != 2 {
+		t.Errorf("未启用空结果缓存时不应缓存，loader被调用%d次", calls.Load())
+	}
+}
+
+// TestGetOrLoadUnavailable 测试Redis不可用时直接调用loader，客户端未注册时返回错误
+func TestGetOrLoadUnavailable(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	c := New[int](group, "cache")
+	s.Close()
+
+	n, err := c.GetOrLoad(ctx, "k", func(ctx context.Context, key string) (int, error) { return 42, nil })
+	if err != nil || n != 42 {
+		t.Errorf("Redis不可用时应返回loader的结果: %d %v", n, err)
+	}
+
+	missing := New[int](group, "missing")
+	if _, err := missing.GetOrLoad(ctx, "k", func(ctx context.Context, key string) (int, error) { return 1, nil }); !mgredis.IsErrClientNotFound(err) {
+		t.Errorf("预期ErrClientNotFound，实际为: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 缓存值的编解码器
+type Codec interface {
+	// Marshal 将值编码为字节
+	Marshal(v any) ([]byte, error)
+	// Unmarshal 将字节解码到v，v为指针
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSON 使用encoding/json编解码，默认的编解码器
+	JSON Codec = jsonCodec{}
+
+	// Gob 使用encoding/gob编解码，接口类型的值需要先通过gob.Register注册
+	Gob Codec = gobCodec{}
+
+	// Msgpack 使用MessagePack编解码，字段标签为msgpack
+	Msgpack Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
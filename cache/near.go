@@ -0,0 +1,588 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultNearMaxEntries 本地缓存的默认最大条目数
+	DefaultNearMaxEntries = 10000
+
+	// DefaultNearMaxBytes 本地缓存的默认最大字节数（键和值的长度之和）
+	DefaultNearMaxBytes = 64 << 20
+
+	// DefaultNearTTL 本地缓存条目的默认有效期，限制失效通知丢失时读到旧值的时长
+	DefaultNearTTL = time.Minute
+
+	// DefaultNearRetryInterval 失效通知连接断开后重新建立的默认间隔
+	DefaultNearRetryInterval = time.Second
+
+	// trackingChannel Redis发送客户端缓存失效通知的频道
+	trackingChannel = "__redis__:invalidate"
+
+	// nearPollInterval 接收失效通知时检查关闭和客户端替换的间隔
+	nearPollInterval = 500 * time.Millisecond
+)
+
+// InvalidationMode 本地缓存的失效通知方式
+type InvalidationMode string
+
+const (
+	// InvalidateAuto 优先使用CLIENT TRACKING，服务端不支持时使用广播频道，默认方式
+	InvalidateAuto InvalidationMode = "auto"
+
+	// InvalidateTracking 使用Redis 6+的CLIENT TRACKING广播模式，
+	// 任何客户端对匹配前缀的键的修改都会通知本地缓存失效
+	InvalidateTracking InvalidationMode = "tracking"
+
+	// InvalidateBroadcast 通过Near的Set和Delete在广播频道上发布失效的键，
+	// 不经过Near的写入不会通知失效，只能依赖本地有效期
+	InvalidateBroadcast InvalidationMode = "broadcast"
+)
+
+// NearOption 本地缓存选项
+type NearOption func(*nearOptions)
+
+type nearOptions struct {
+	maxEntries    int
+	maxBytes      int
+	ttl           time.Duration
+	mode          InvalidationMode
+	prefixes      []string
+	channel       string
+	retryInterval time.Duration
+}
+
+// WithNearMaxEntries 设置本地缓存的最大条目数
+func WithNearMaxEntries(n int) NearOption {
+	return func(o *nearOptions) {
+		if n > 0 {
+			o.maxEntries = n
+		}
+	}
+}
+
+// WithNearMaxBytes 设置本地缓存的最大字节数，超过时淘汰最近最少使用的条目
+func WithNearMaxBytes(n int) NearOption {
+	return func(o *nearOptions) {
+		if n > 0 {
+			o.maxBytes = n
+		}
+	}
+}
+
+// WithNearTTL 设置本地缓存条目的有效期，Redis中的键先于该时间过期时以键的过期时间为准
+func WithNearTTL(ttl time.Duration) NearOption {
+	return func(o *nearOptions) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithInvalidation 设置失效通知方式，默认为InvalidateAuto
+func WithInvalidation(mode InvalidationMode) NearOption {
+	return func(o *nearOptions) {
+		o.mode = mode
+	}
+}
+
+// WithNearPrefixes 设置CLIENT TRACKING跟踪的键前缀，默认跟踪所有键
+// 只有匹配前缀的键会进入本地缓存
+func WithNearPrefixes(prefixes ...string) NearOption {
+	return func(o *nearOptions) {
+		o.prefixes = prefixes
+	}
+}
+
+// WithNearChannel 设置广播方式使用的频道，默认为"mgredis:near:{name}"
+// 频道在Redis服务端内全局有效，连接同一服务端的同名实例共用默认频道，只会收到多余的失效通知
+func WithNearChannel(channel string) NearOption {
+	return func(o *nearOptions) {
+		o.channel = channel
+	}
+}
+
+// WithNearRetryInterval 设置失效通知连接断开后重新建立的间隔
+func WithNearRetryInterval(d time.Duration) NearOption {
+	return func(o *nearOptions) {
+		if d > 0 {
+			o.retryInterval = d
+		}
+	}
+}
+
+// NearStats 本地缓存的统计信息
+type NearStats struct {
+	// Name 实例名
+	Name string
+	// Mode 当前使用的失效通知方式，失效通知未建立时为空
+	Mode InvalidationMode
+
+	// Hits 本地命中次数
+	Hits uint64
+	// Misses 本地未命中次数
+	Misses uint64
+	// Invalidations 收到失效通知后删除的条目数
+	Invalidations uint64
+	// Evictions 超过容量被淘汰的条目数
+	Evictions uint64
+
+	// Entries 当前条目数
+	Entries int
+	// Bytes 当前字节数
+	Bytes int
+}
+
+// nearEntry 本地缓存条目
+type nearEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// nearFetch 进行中的Redis读取，期间收到失效通知时读取结果不写入本地缓存
+type nearFetch struct {
+	refs        int
+	invalidated bool
+}
+
+// Near 位于组中命名客户端之前的本地缓存（二级缓存）
+//
+// 读取时优先使用进程内的LRU缓存，未命中时读取Redis并写入本地。本地缓存通过CLIENT TRACKING
+// 或广播频道保持一致：收到失效通知时删除对应条目，通知连接断开时清空本地缓存且在重新建立前不使用本地缓存。
+type Near struct {
+	group mgredis.Group
+	name  string
+	opts  nearOptions
+
+	mu       sync.Mutex
+	lru      *list.List
+	items    map[string]*list.Element
+	bytes    int
+	fetching map[string]*nearFetch
+	mode     InvalidationMode // 当前使用的失效通知方式，为空时不使用本地缓存
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+	evictions     atomic.Uint64
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewNear 创建本地缓存并在后台建立失效通知，name为组中已注册的客户端名称，不再使用时应调用Close
+func NewNear(group mgredis.Group, name string, opts ...NearOption) *Near {
+	o := nearOptions{
+		maxEntries:    DefaultNearMaxEntries,
+		maxBytes:      DefaultNearMaxBytes,
+		ttl:           DefaultNearTTL,
+		mode:          InvalidateAuto,
+		channel:       "mgredis:near:" + name,
+		retryInterval: DefaultNearRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	n := &Near{
+		group:    group,
+		name:     name,
+		opts:     o,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		fetching: make(map[string]*nearFetch),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Get 读取键的值，本地未命中时读取Redis，键不存在时返回ErrNotFound
+// 返回的切片与本地缓存共享，不应修改
+func (n *Near) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, ok := n.load(key); ok {
+		n.hits.Add(1)
+		return value, nil
+	}
+	n.misses.Add(1)
+
+	client, err := n.group.Get(ctx, n.name)
+	if err != nil {
+		return nil, err
+	}
+	f := n.beginFetch(key)
+	if f == nil {
+		value, err := client.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			err = ErrNotFound
+		}
+		return value, err
+	}
+
+	// 同时读取键的剩余过期时间，本地条目不晚于Redis中的键过期
+	var (
+		get  *redis.StringCmd
+		pttl *redis.DurationCmd
+	)
+	_, _ = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	value, err := get.Bytes()
+	if errors.Is(err, redis.Nil) {
+		err = ErrNotFound
+	}
+	ttl, ttlErr := pttl.Result()
+	n.endFetch(key, f, value, ttl, err == nil && ttlErr == nil)
+	return value, err
+}
+
+// Set 写入Redis并使本地缓存失效，ttl为0表示不过期
+func (n *Near) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	client, err := n.group.Get(ctx, n.name)
+	if err != nil {
+		return err
+	}
+	err = client.Set(ctx, key, value, ttl).Err()
+	n.invalidate(key)
+	if err != nil {
+		return err
+	}
+	return n.publish(ctx, client, key)
+}
+
+// Delete 删除Redis中的键并使本地缓存失效
+func (n *Near) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	client, err := n.group.Get(ctx, n.name)
+	if err != nil {
+		return err
+	}
+	err = client.Del(ctx, keys...).Err()
+	n.invalidate(keys...)
+	if err != nil {
+		return err
+	}
+	return n.publish(ctx, client, keys...)
+}
+
+// Stats 返回统计信息
+func (n *Near) Stats() NearStats {
+	n.mu.Lock()
+	entries, bytes, mode := n.lru.Len(), n.bytes, n.mode
+	n.mu.Unlock()
+
+	return NearStats{
+		Name:          n.name,
+		Mode:          mode,
+		Hits:          n.hits.Load(),
+		Misses:        n.misses.Load(),
+		Invalidations: n.invalidations.Load(),
+		Evictions:     n.evictions.Load(),
+		Entries:       entries,
+		Bytes:         bytes,
+	}
+}
+
+// Close 停止失效通知并清空本地缓存
+func (n *Near) Close() error {
+	select {
+	case <-n.quit:
+	default:
+		close(n.quit)
+	}
+	<-n.done
+	return nil
+}
+
+// publish 未使用CLIENT TRACKING时在广播频道上发布失效的键
+func (n *Near) publish(ctx context.Context, client *redis.Client, keys ...string) error {
+	n.mu.Lock()
+	mode := n.mode
+	n.mu.Unlock()
+	if mode == InvalidateTracking || n.opts.mode == InvalidateTracking {
+		return nil
+	}
+
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Publish(ctx, n.opts.channel, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// load 读取本地缓存，失效通知未建立时不使用本地缓存
+func (n *Near) load(key string) ([]byte, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.mode == "" {
+		return nil, false
+	}
+	el, ok := n.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*nearEntry)
+	if time.Now().After(entry.expiresAt) {
+		n.remove(el)
+		return nil, false
+	}
+	n.lru.MoveToFront(el)
+	return entry.value, true
+}
+
+// beginFetch 记录进行中的读取，失效通知未建立或键不匹配前缀时返回nil
+func (n *Near) beginFetch(key string) *nearFetch {
+	if !n.tracked(key) {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.mode == "" {
+		return nil
+	}
+	f, ok := n.fetching[key]
+	if !ok {
+		f = &nearFetch{}
+		n.fetching[key] = f
+	}
+	f.refs++
+	return f
+}
+
+// endFetch 结束读取，读取期间未收到失效通知时写入本地缓存
+// ttl为键在Redis中的剩余过期时间（PTTL的结果），本地条目的有效期不超过该时间
+func (n *Near) endFetch(key string, f *nearFetch, value []byte, ttl time.Duration, store bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if f.refs--; f.refs == 0 {
+		delete(n.fetching, key)
+	}
+	// PTTL返回-2表示键在GET之后已被删除
+	if !store || f.invalidated || n.mode == "" || ttl == -2 {
+		return
+	}
+	expiresAt := time.Now().Add(n.opts.ttl)
+	if ttl >= 0 && ttl < n.opts.ttl {
+		expiresAt = time.Now().Add(ttl)
+	}
+	size := len(key) + len(value)
+	if size > n.opts.maxBytes {
+		return
+	}
+
+	if el, ok := n.items[key]; ok {
+		n.remove(el)
+	}
+	n.items[key] = n.lru.PushFront(&nearEntry{key: key, value: value, expiresAt: expiresAt})
+	n.bytes += size
+	for n.lru.Len() > n.opts.maxEntries || n.bytes > n.opts.maxBytes {
+		n.remove(n.lru.Back())
+		n.evictions.Add(1)
+	}
+}
+
+// tracked 判断键是否匹配跟踪的前缀
+func (n *Near) tracked(key string) bool {
+	if len(n.opts.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range n.opts.prefixes {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidate 删除本地缓存中的键，并使进行中的读取结果不写入本地缓存
+func (n *Near) invalidate(keys ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, key := range keys {
+		if f, ok := n.fetching[key]; ok {
+			f.invalidated = true
+		}
+		if el, ok := n.items[key]; ok {
+			n.remove(el)
+			n.invalidations.Add(1)
+		}
+	}
+}
+
+// setMode 设置当前的失效通知方式并清空本地缓存，mode为空时停止使用本地缓存
+func (n *Near) setMode(mode InvalidationMode) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.mode = mode
+	n.lru.Init()
+	n.items = make(map[string]*list.Element)
+	n.bytes = 0
+	for _, f := range n.fetching {
+		f.invalidated = true
+	}
+}
+
+// remove 删除条目，调用方持有mu
+func (n *Near) remove(el *list.Element) {
+	entry := n.lru.Remove(el).(*nearEntry)
+	delete(n.items, entry.key)
+	n.bytes -= len(entry.key) + len(entry.value)
+}
+
+// run 建立并维持失效通知，连接断开后每隔重试间隔重新建立，直到Close
+func (n *Near) run() {
+	defer close(n.done)
+	defer n.setMode("")
+
+	for {
+		_ = n.subscribe()
+		n.setMode("")
+
+		select {
+		case <-n.quit:
+			return
+		case <-time.After(n.opts.retryInterval):
+		}
+	}
+}
+
+// subscribe 建立失效通知并接收，直到连接出错、组中的客户端被替换或Close
+//
+// 通知使用独立的RESP2连接：CLIENT TRACKING的通知重定向到订阅了__redis__:invalidate的连接，
+// 通知连接断开后重新建立时连接ID会变化，因此出错时整体重建而不是由go-redis自动重连。
+func (n *Near) subscribe() error {
+	ctx := context.Background()
+	client, err := n.group.Get(ctx, n.name)
+	if err != nil {
+		return err
+	}
+
+	var id atomic.Int64
+	opt := *client.Options()
+	opt.Protocol = 2
+	opt.PoolSize = 1
+	opt.MinIdleConns = 0
+	onConnect := opt.OnConnect
+	opt.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if onConnect != nil {
+			if err := onConnect(ctx, cn); err != nil {
+				return err
+			}
+		}
+		// 不支持CLIENT ID的服务端只能使用广播方式
+		id.Store(cn.ClientID(ctx).Val())
+		return nil
+	}
+	helper := redis.NewClient(&opt)
+	defer helper.Close()
+
+	mode := n.opts.mode
+	var (
+		sub  *redis.PubSub
+		conn *redis.Conn
+	)
+	if mode == InvalidateAuto || mode == InvalidateTracking {
+		sub, conn, err = n.track(ctx, helper, &id)
+		switch {
+		case err == nil:
+			mode = InvalidateTracking
+		case mode == InvalidateTracking:
+			return err
+		default:
+			mode = InvalidateBroadcast
+		}
+	}
+	if mode == InvalidateBroadcast {
+		sub = helper.Subscribe(ctx, n.opts.channel)
+		if _, err := sub.Receive(ctx); err != nil {
+			_ = sub.Close()
+			return err
+		}
+	}
+	defer sub.Close()
+	if conn != nil {
+		defer conn.Close()
+	}
+
+	n.setMode(mode)
+	for {
+		select {
+		case <-n.quit:
+			return nil
+		default:
+		}
+		if current, err := n.group.Get(ctx, n.name); err != nil || current != client {
+			return err
+		}
+
+		msg, err := sub.ReceiveTimeout(ctx, nearPollInterval)
+		if err != nil {
+			var netErr interface{ Timeout() bool }
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return err
+			}
+			// 空闲时检查跟踪连接，跟踪连接断开后不会再收到通知
+			if conn != nil {
+				if err := conn.Ping(ctx).Err(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if m, ok := msg.(*redis.Message); ok {
+			if m.Payload != "" {
+				n.invalidate(m.Payload)
+			}
+			n.invalidate(m.PayloadSlice...)
+		}
+	}
+}
+
+// track 订阅__redis__:invalidate并在另一个连接上开启重定向到该订阅连接的CLIENT TRACKING
+func (n *Near) track(ctx context.Context, helper *redis.Client, id *atomic.Int64) (*redis.PubSub, *redis.Conn, error) {
+	sub := helper.Subscribe(ctx, trackingChannel)
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, nil, err
+	}
+	redirect := id.Load()
+	if redirect == 0 {
+		_ = sub.Close()
+		return nil, nil, errors.New("mgredis/cache: CLIENT ID not supported")
+	}
+
+	args := []interface{}{"CLIENT", "TRACKING", "ON", "REDIRECT", redirect, "BCAST"}
+	for _, prefix := range n.opts.prefixes {
+		args = append(args, "PREFIX", prefix)
+	}
+	conn := helper.Conn()
+	cmd := redis.NewStatusCmd(ctx, args...)
+	if err := conn.Process(ctx, cmd); err != nil {
+		_ = conn.Close()
+		_ = sub.Close()
+		return nil, nil, err
+	}
+	return sub, conn, nil
+}
@@ -0,0 +1,299 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+// waitMode 等待本地缓存的失效通知方式
+func waitMode(t *testing.T, n *Near, mode InvalidationMode) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for n.Stats().Mode != mode && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := n.Stats().Mode; got != mode {
+		t.Fatalf("预期失效通知方式为%q，实际为%q", mode, got)
+	}
+}
+
+// TestNearBroadcast 测试本地命中，以及通过广播频道使其他实例的本地缓存失效
+func TestNearBroadcast(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+
+	// miniredis不支持CLIENT TRACKING，默认方式回退到广播频道
+	n1 := NewNear(group, "cache")
+	defer n1.Close()
+	n2 := NewNear(group, "cache", WithInvalidation(InvalidateBroadcast))
+	defer n2.Close()
+	waitMode(t, n1, InvalidateBroadcast)
+	waitMode(t, n2, InvalidateBroadcast)
+
+	_ = s.Set("k", "v1")
+	for i := 0; i < 3; i++ {
+		if got, err := n1.Get(ctx, "k"); err != nil || string(got) != "v1" {
+			t.Fatalf("读取结果不符: %q %v", got, err)
+		}
+	}
+	if _, err := n1.Get(ctx, "missing"); !IsErrNotFound(err) {
+		t.Errorf("键不存在时应返回ErrNotFound，实际为: %v", err)
+	}
+	st := n1.Stats()
+	if st.Name != "cache" || st.Hits != 2 || st.Misses != 2 || st.Entries != 1 || st.Bytes != 3 {
+		t.Errorf("统计信息不符: %+v", st)
+	}
+
+	// 另一个实例写入后本地缓存失效
+	if err := n2.Set(ctx, "k", []byte("v2"), 0); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for n1.Stats().Invalidations == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got, _ := n1.Get(ctx, "k"); string(got) != "v2" {
+		t.Errorf("失效后应读取新值，实际为%q", got)
+	}
+
+	if err := n2.Delete(ctx, "k"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for n1.Stats().Entries != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := n1.Get(ctx, "k"); !IsErrNotFound(err) {
+		t.Errorf("删除后应返回ErrNotFound，实际为: %v", err)
+	}
+}
+
+// TestNearLRU 测试按条目数和字节数淘汰，以及本地有效期
+func TestNearLRU(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	for _, k := range []string{"a", "b", "c"} {
+		_ = s.Set(k, "0123456789")
+	}
+
+	n := NewNear(group, "cache", WithNearMaxEntries(2), WithNearMaxBytes(25))
+	defer n.Close()
+	waitMode(t, n, InvalidateBroadcast)
+
+	_, _ = n.Get(ctx, "a")
+	_, _ = n.Get(ctx, "b")
+	_, _ = n.Get(ctx, "a") // a最近使用
+	_, _ = n.Get(ctx, "c") // 淘汰b
+	if st := n.Stats(); st.Entries != 2 || st.Evictions != 1 || st.Bytes != 22 {
+		t.Errorf("按条目数淘汰后统计信息不符: %+v", st)
+	}
+	hits := n.Stats().Hits
+	_, _ = n.Get(ctx, "a")
+	_, _ = n.Get(ctx, "b")
+	if st := n.Stats(); st.Hits != hits+1 {
+		t.Errorf("应淘汰最近最少使用的条目: %+v", st)
+	}
+
+	// 按字节数淘汰：a和b各占11字节，big占15字节，两个条目就超过25字节
+	_ = s.Set("big", "012345678901")
+	_, _ = n.Get(ctx, "big")
+	if st := n.Stats(); st.Bytes != 15 || st.Entries != 1 || st.Evictions != 4 {
+		t.Errorf("按字节数淘汰后统计信息不符: %+v", st)
+	}
+
+	short := NewNear(group, "cache", WithNearTTL(20*time.Millisecond))
+	defer short.Close()
+	waitMode(t, short, InvalidateBroadcast)
+	_, _ = short.Get(ctx, "a")
+	time.Sleep(30 * time.Millisecond)
+	_, _ = short.Get(ctx, "a")
+	if st := short.Stats(); st.Hits != 0 || st.Misses != 2 {
+		t.Errorf("本地条目过期后应重新读取: %+v", st)
+	}
+}
+
+// TestNearReconnect 测试失效通知断开时清空并停止使用本地缓存，恢复后重新使用
+func TestNearReconnect(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	_ = s.Set("k", "v")
+
+	n := NewNear(group, "cache", WithNearRetryInterval(10*time.Millisecond))
+	defer n.Close()
+	waitMode(t, n, InvalidateBroadcast)
+	_, _ = n.Get(ctx, "k")
+
+	s.Close()
+	waitMode(t, n, "")
+	if st := n.Stats(); st.Entries != 0 {
+		t.Errorf("失效通知断开时应清空本地缓存: %+v", st)
+	}
+
+	if err := s.Restart(); err != nil {
+		t.Fatalf("重启失败: %v", err)
+	}
+	_ = s.Set("k", "v")
+	waitMode(t, n, InvalidateBroadcast)
+	_, _ = n.Get(ctx, "k")
+	_, _ = n.Get(ctx, "k")
+	if st := n.Stats(); st.Hits != 1 {
+		t.Errorf("恢复后应重新使用本地缓存: %+v", st)
+	}
+}
+
+// TestNearTrackingUnsupported 测试服务端不支持CLIENT TRACKING时不使用本地缓存
+func TestNearTrackingUnsupported(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	_ = s.Set("k", "v")
+
+	n := NewNear(group, "cache", WithInvalidation(InvalidateTracking), WithNearRetryInterval(10*time.Millisecond))
+	defer n.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if got, err := n.Get(ctx, "k"); err != nil || string(got) != "v" {
+			t.Fatalf("读取结果不符: %q %v", got, err)
+		}
+	}
+	if st := n.Stats(); st.Mode != "" || st.Hits != 0 || st.Entries != 0 {
+		t.Errorf("失效通知未建立时不应使用本地缓存: %+v", st)
+	}
+}
+
+// TestCacheWithNear 测试Cache使用本地缓存作为一级缓存
+func TestCacheWithNear(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t)
+
+	n := NewNear(group, "cache")
+	defer n.Close()
+	waitMode(t, n, InvalidateBroadcast)
+	c := New[user](group, "cache", WithNear(n), WithKeyPrefix("user:"))
+
+	calls := 0
+	loader := func(ctx context.Context, key string) (user, error) {
+		calls++
+		return user{ID: 1, Name: "tom"}, nil
+	}
+	for i := 0; i < 3; i++ {
+		if u, err := c.GetOrLoad(ctx, "1", loader); err != nil || u.Name != "tom" {
+			t.Fatalf("读取结果不符: %+v %v", u, err)
+		}
+	}
+	if st := n.Stats(); calls != 1 || st.Hits != 1 || st.Entries != 1 {
+		t.Errorf("回填后应从本地缓存读取: calls=%d %+v", calls, st)
+	}
+
+	_ = c.Delete(ctx, "1")
+	if _, err := c.Get(ctx, "1"); !IsErrNotFound(err) {
+		t.Errorf("删除后应返回ErrNotFound，实际为: %v", err)
+	}
+}
+
+// TestNearKeyTTL 测试本地条目不晚于Redis中的键过期
+func TestNearKeyTTL(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+
+	n := NewNear(group, "cache")
+	defer n.Close()
+	waitMode(t, n, InvalidateBroadcast)
+	c := New[user](group, "cache", WithNear(n), WithNegativeTTL(50*time.Millisecond))
+
+	calls := 0
+	loader := func(ctx context.Context, key string) (user, error) {
+		calls++
+		return user{}, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrLoad(ctx, "1", loader); !IsErrNotFound(err) {
+			t.Fatalf("预期ErrNotFound，实际为: %v", err)
+		}
+	}
+	if st := n.Stats(); calls != 1 || st.Hits != 1 || st.Entries != 1 {
+		t.Fatalf("空结果应缓存到本地: calls=%d %+v", calls, st)
+	}
+
+	// 空结果标记在Redis中过期后，本地条目同时过期
+	time.Sleep(60 * time.Millisecond)
+	s.FastForward(60 * time.Millisecond)
+	if _, err := c.GetOrLoad(ctx, "1", loader); !IsErrNotFound(err) || calls != 2 {
+		t.Errorf("空结果标记过期后应重新加载: calls=%d %v", calls, err)
+	}
+
+	// 没有过期时间的键使用本地有效期
+	_ = s.Set("k", "v")
+	_, _ = n.Get(ctx, "k")
+	time.Sleep(60 * time.Millisecond)
+	hits := n.Stats().Hits
+	if _, _ = n.Get(ctx, "k"); n.Stats().Hits != hits+1 {
+		t.Errorf("没有过期时间的键应使用本地有效期: %+v", n.Stats())
+	}
+}
+
+// TestNearTracking 测试CLIENT TRACKING的失效通知，以及重定向目标的连接断开后重新建立跟踪
+func TestNearTracking(t *testing.T) {
+	ctx := context.Background()
+	s := newTrackingRedis(t)
+	group := mgredis.New()
+	defer group.Close(ctx)
+	_, _ = group.Register(ctx, "cache", mgredis.RedisConfig{Addr: s.Addr()})
+
+	// 其他客户端的写入
+	writer := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer writer.Close()
+
+	n := NewNear(group, "cache", WithNearRetryInterval(10*time.Millisecond))
+	defer n.Close()
+	waitMode(t, n, InvalidateTracking)
+
+	// expectInvalidated 读取并缓存当前值，其他客户端写入新值后本地条目失效，再次读取得到新值
+	expectInvalidated := func(old, value string) {
+		t.Helper()
+		if got, err := n.Get(ctx, "k"); err != nil || string(got) != old {
+			t.Fatalf("读取结果不符: %q %v", got, err)
+		}
+		if got, _ := n.Get(ctx, "k"); string(got) != old || n.Stats().Entries != 1 {
+			t.Fatalf("应从本地缓存读取: %q %+v", got, n.Stats())
+		}
+
+		before := n.Stats().Invalidations
+		if err := writer.Set(ctx, "k", value, 0).Err(); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for n.Stats().Invalidations == before && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got, _ := n.Get(ctx, "k"); string(got) != value {
+			t.Errorf("收到失效通知后应读取新值，实际为%q", got)
+		}
+	}
+
+	_ = writer.Set(ctx, "k", "v1", 0)
+	first, subscribed := s.Redirect()
+	if !subscribed {
+		t.Fatalf("CLIENT TRACKING应重定向到订阅连接%d", first)
+	}
+	expectInvalidated("v1", "v2")
+
+	// 重定向目标的连接断开后清空本地缓存，并重定向到新的订阅连接
+	s.DropSubscribers()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if id, subscribed := s.Redirect(); id != first && subscribed && n.Stats().Mode == InvalidateTracking {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if id, subscribed := s.Redirect(); id == first || !subscribed {
+		t.Fatalf("重新建立后应重定向到新的订阅连接，实际为%d（原为%d）", id, first)
+	}
+	waitMode(t, n, InvalidateTracking)
+	expectInvalidated("v2", "v3")
+}
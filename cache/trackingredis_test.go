@@ -0,0 +1,299 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// trackingRedis 支持CLIENT TRACKING重定向的最小RESP2服务器
+//
+// 只实现本地缓存需要的命令：GET、SET、DEL、PTTL、CLIENT ID、CLIENT TRACKING和SUBSCRIBE。
+// 任何连接修改键时，向所有开启跟踪的连接的重定向目标推送__redis__:invalidate消息。
+type trackingRedis struct {
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu     sync.Mutex
+	nextID int64
+	data   map[string]string
+	conns  map[int64]*trackingConn
+	// redirects 开启跟踪的连接ID => 重定向目标的连接ID
+	redirects map[int64]int64
+	// lastRedirect 最近一次CLIENT TRACKING的重定向目标
+	lastRedirect int64
+}
+
+// trackingConn 服务端的一个客户端连接
+type trackingConn struct {
+	id         int64
+	c          net.Conn
+	subscribed bool
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+}
+
+// newTrackingRedis 启动一个监听本地随机端口的trackingRedis，测试结束时自动关闭
+func newTrackingRedis(t *testing.T) *trackingRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动trackingRedis失败: %v", err)
+	}
+	s := &trackingRedis{
+		ln:        ln,
+		data:      make(map[string]string),
+		conns:     make(map[int64]*trackingConn),
+		redirects: make(map[int64]int64),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr 返回监听地址
+func (s *trackingRedis) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Redirect 返回最近一次CLIENT TRACKING的重定向目标，以及该目标当前是否为已订阅的连接
+func (s *trackingRedis) Redirect() (id int64, subscribed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conns[s.lastRedirect]
+	return s.lastRedirect, ok && c.subscribed
+}
+
+// DropSubscribers 断开所有订阅连接，模拟重定向目标的连接断开
+func (s *trackingRedis) DropSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		if c.subscribed {
+			_ = c.c.Close()
+		}
+	}
+}
+
+// Close 关闭服务器及所有连接
+func (s *trackingRedis) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for _, c := range s.conns {
+		_ = c.c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *trackingRedis) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.nextID++
+		c := &trackingConn{id: s.nextID, c: nc, w: bufio.NewWriter(nc)}
+		s.conns[c.id] = c
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *trackingRedis) handle(c *trackingConn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c.id)
+		delete(s.redirects, c.id)
+		s.mu.Unlock()
+		_ = c.c.Close()
+	}()
+
+	r := bufio.NewReader(c.c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		reply, invalidated := s.exec(c, args)
+		if err := c.write(reply); err != nil {
+			return
+		}
+		s.notify(invalidated)
+	}
+}
+
+// exec 执行一条命令，返回回复和被修改的键
+func (s *trackingRedis) exec(c *trackingConn, args []string) (interface{}, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "HELLO":
+		// 不支持RESP3，让客户端回退到RESP2
+		return respError("ERR unknown command 'HELLO'"), nil
+	case "PING":
+		return respSimple("PONG"), nil
+	case "CLIENT":
+		switch strings.ToUpper(args[1]) {
+		case "ID":
+			return c.id, nil
+		case "TRACKING":
+			for i := 2; i+1 < len(args); i++ {
+				if strings.EqualFold(args[i], "REDIRECT") {
+					id, _ := strconv.ParseInt(args[i+1], 10, 64)
+					s.redirects[c.id] = id
+					s.lastRedirect = id
+				}
+			}
+		}
+		return respSimple("OK"), nil
+	case "SUBSCRIBE":
+		c.subscribed = true
+		return []interface{}{"subscribe", args[1], int64(1)}, nil
+	case "GET":
+		v, ok := s.data[args[1]]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	case "PTTL":
+		if _, ok := s.data[args[1]]; !ok {
+			return int64(-2), nil
+		}
+		return int64(-1), nil
+	case "SET":
+		s.data[args[1]] = args[2]
+		return respSimple("OK"), args[1:2]
+	case "DEL":
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				n++
+			}
+		}
+		return n, args[1:]
+	default:
+		return respError(fmt.Sprintf("ERR unknown command '%s'", args[0])), nil
+	}
+}
+
+// notify 向开启跟踪的连接的重定向目标推送失效消息
+func (s *trackingRedis) notify(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	s.mu.Lock()
+	var targets []*trackingConn
+	for _, redirect := range s.redirects {
+		if c, ok := s.conns[redirect]; ok && c.subscribed {
+			targets = append(targets, c)
+		}
+	}
+	s.mu.Unlock()
+
+	payload := make([]interface{}, len(keys))
+	for i, key := range keys {
+		payload[i] = key
+	}
+	for _, c := range targets {
+		_ = c.write([]interface{}{"message", trackingChannel, payload})
+	}
+}
+
+// write 写入一条回复，推送消息和命令回复可能并发写入同一连接
+func (c *trackingConn) write(reply interface{}) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	writeReply(c.w, reply)
+	return c.w.Flush()
+}
+
+// respSimple RESP简单字符串回复，如 +OK
+type respSimple string
+
+// respError RESP错误回复，如 -ERR xxx
+type respError string
+
+// readCommand 读取一条RESP数组命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// writeReply 将回复编码为RESP2格式
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		_, _ = w.WriteString("$-1\r\n")
+	case respSimple:
+		_, _ = fmt.Fprintf(w, "+%s\r\n", v)
+	case respError:
+		_, _ = fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		_, _ = fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		_, _ = fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		_, _ = fmt.Fprintf(w, "-ERR unsupported reply %T\r\n", v)
+	}
+}
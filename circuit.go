@@ -0,0 +1,270 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultCircuitOpenDuration 熔断的默认持续时间
+	DefaultCircuitOpenDuration = 10 * time.Second
+
+	// DefaultCircuitHalfOpenProbes 半开状态默认允许通过的试探请求数
+	DefaultCircuitHalfOpenProbes = 1
+)
+
+// CircuitBreakerConfig 熔断器配置
+//
+// 连续FailureThreshold次命令因超时、连接失败等故障出错后熔断，熔断期间命令立即返回ErrCircuitOpen，
+// 不再等待DialTimeout或ReadTimeout；OpenDuration后进入半开状态，放行HalfOpenProbes个试探请求，
+// 全部成功则恢复，任一失败则重新熔断。redis.Nil、WRONGTYPE等命令错误不计为故障。
+type CircuitBreakerConfig struct {
+	// FailureThreshold 连续失败多少次后熔断，为0表示不启用熔断
+	FailureThreshold int `json:"failure_threshold"`
+
+	// OpenDuration 熔断持续时间，到期后进入半开状态，默认为10秒
+	OpenDuration time.Duration `json:"open_duration"`
+
+	// HalfOpenProbes 半开状态允许通过的试探请求数，默认为1
+	HalfOpenProbes int `json:"half_open_probes"`
+}
+
+// Enabled 是否启用熔断
+func (c *CircuitBreakerConfig) Enabled() bool {
+	return c.FailureThreshold > 0
+}
+
+// CheckAndSetDefaults 设置默认值，未启用熔断时不做任何事
+func (c *CircuitBreakerConfig) CheckAndSetDefaults() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.OpenDuration <= 0 {
+		c.OpenDuration = DefaultCircuitOpenDuration
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = DefaultCircuitHalfOpenProbes
+	}
+	return nil
+}
+
+// UnmarshalJSON 解析JSON配置，open_duration同时支持"3s"格式的字符串和表示秒数的整数
+func (c *CircuitBreakerConfig) UnmarshalJSON(data []byte) error {
+	type plain CircuitBreakerConfig
+	aux := struct {
+		*plain
+		OpenDuration *jsonDuration `json:"open_duration"`
+	}{
+		plain:        (*plain)(c),
+		OpenDuration: (*jsonDuration)(&c.OpenDuration),
+	}
+	return json.Unmarshal(data, &aux)
+}
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	// CircuitClosed 正常放行，未启用熔断的客户端始终为该状态
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen 放行少量试探请求
+	CircuitHalfOpen
+	// CircuitOpen 熔断中，命令立即失败
+	CircuitOpen
+)
+
+// String 返回熔断器状态的名称
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitOutcome 放行请求的结果
+type circuitOutcome int
+
+const (
+	// circuitSuccess 实例正常应答，包括redis.Nil等命令错误
+	circuitSuccess circuitOutcome = iota
+	// circuitFailure 实例故障
+	circuitFailure
+	// circuitNeutral 调用方的上下文超时或取消，无法判断实例是否正常
+	circuitNeutral
+)
+
+// circuitBreaker 单个客户端的熔断器，同时作为go-redis钩子
+type circuitBreaker struct {
+	cfg  CircuitBreakerConfig
+	opts *options
+	key  InstanceKey
+	rc   RedisConfig
+	now  func() time.Time
+
+	mu         sync.Mutex
+	state      CircuitState
+	failures   int       // 关闭状态下的连续失败次数
+	openedAt   time.Time // 进入熔断的时间
+	probes     int       // 半开状态已放行的试探请求数
+	successes  int       // 半开状态成功的试探请求数
+	generation uint64    // 每次状态变化递增，忽略状态变化前放行的请求结果
+}
+
+// newCircuitBreaker 创建熔断器，未启用熔断时返回nil
+func newCircuitBreaker(opts *options, key InstanceKey, cfg RedisConfig) *circuitBreaker {
+	if !cfg.CircuitBreaker.Enabled() {
+		return nil
+	}
+	cb := cfg.CircuitBreaker
+	_ = cb.CheckAndSetDefaults()
+	return &circuitBreaker{cfg: cb, opts: opts, key: key, rc: cfg, now: time.Now}
+}
+
+// State 返回当前状态，熔断时间已到时返回半开
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenDuration {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow 判断是否放行请求，返回放行时的状态版本
+func (b *circuitBreaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenDuration {
+			return 0, false
+		}
+		b.setState(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return 0, false
+		}
+		b.probes++
+	}
+	return b.generation, true
+}
+
+// done 记录放行请求的结果
+// 中性结果不改变成功和失败计数，半开状态下释放试探名额，允许其他请求继续试探
+func (b *circuitBreaker) done(generation uint64, outcome circuitOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitClosed:
+		switch outcome {
+		case circuitSuccess:
+			b.failures = 0
+		case circuitFailure:
+			b.failures++
+			if b.failures >= b.cfg.FailureThreshold {
+				b.setState(CircuitOpen)
+			}
+		}
+	case CircuitHalfOpen:
+		switch outcome {
+		case circuitFailure:
+			b.setState(CircuitOpen)
+			return
+		case circuitNeutral:
+			b.probes--
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+// setState 切换状态并记录日志，调用方需持有锁
+func (b *circuitBreaker) setState(to CircuitState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if to == CircuitOpen {
+		b.openedAt = b.now()
+	}
+
+	level := slog.LevelInfo
+	if to == CircuitOpen {
+		level = slog.LevelWarn
+	}
+	b.opts.log(level, "redis circuit breaker state changed", b.key, b.rc, "from", from.String(), "to", to.String())
+}
+
+// openError 熔断时返回的错误
+func (b *circuitBreaker) openError() error {
+	return &Error{Op: OpCommand, Group: b.key.Group, Name: b.key.Name, Addr: instanceAddr(b.rc), Kind: ErrCircuitOpen}
+}
+
+// circuitOutcomeOf 判断请求结果，调用方自己的上下文超时或取消为中性结果
+func circuitOutcomeOf(ctx context.Context, err error) circuitOutcome {
+	switch Classify(err) {
+	case ClassConnRefused, ClassConnection, ClassLoading:
+		return circuitFailure
+	case ClassTimeout:
+		if ctx.Err() != nil {
+			return circuitNeutral
+		}
+		return circuitFailure
+	case ClassCanceled:
+		return circuitNeutral
+	default:
+		return circuitSuccess
+	}
+}
+
+func (b *circuitBreaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (b *circuitBreaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		generation, ok := b.allow()
+		if !ok {
+			err := b.openError()
+			cmd.SetErr(err)
+			return err
+		}
+		err := next(ctx, cmd)
+		b.done(generation, circuitOutcomeOf(ctx, err))
+		return err
+	}
+}
+
+func (b *circuitBreaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		generation, ok := b.allow()
+		if !ok {
+			err := b.openError()
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		b.done(generation, circuitOutcomeOf(ctx, err))
+		return err
+	}
+}
@@ -0,0 +1,204 @@
+package mgredis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newSlowRedis 启动一个fakeRedis，slow为true时GET超过读超时才返回，否则返回nil
+func newSlowRedis(t *testing.T, slow *atomic.Bool) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if !strings.EqualFold(args[0], "GET") {
+			return nil, false
+		}
+		if slow.Load() {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return nil, true
+	})
+}
+
+// TestCircuitBreaker 测试熔断、半开试探和恢复
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	var slow atomic.Bool
+	s := newSlowRedis(t, &slow)
+	metrics := NewMetrics()
+	group := New(WithMetrics(metrics))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:        s.Addr(),
+		ReadTimeout: 20 * time.Millisecond,
+		MaxRetries:  -1,
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenDuration:     100 * time.Millisecond,
+		},
+	})
+	client := group.MustGet(ctx, "cache")
+
+	// redis.Nil不计为故障
+	for i := 0; i < 3; i++ {
+		if err := client.Get(ctx, "k").Err(); !IsErrNil(err) {
+			t.Fatalf("预期redis.Nil，实际为: %v", err)
+		}
+	}
+
+	slow.Store(true)
+	for i := 0; i < 2; i++ {
+		if err := client.Get(ctx, "k").Err(); !IsErrTimeout(err) {
+			t.Fatalf("预期超时，实际为: %v", err)
+		}
+	}
+
+	start := time.Now()
+	err := client.Get(ctx, "k").Err()
+	if !IsErrCircuitOpen(err) || Classify(err) != ClassCircuitOpen {
+		t.Fatalf("预期ErrCircuitOpen，实际为: %v", err)
+	}
+	if d := time.Since(start); d > 10*time.Millisecond {
+		t.Errorf("熔断时应立即返回，实际耗时%v", d)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Op != OpCommand || e.Name != "cache" || e.Addr != s.Addr() {
+		t.Errorf("上下文错误: %v", err)
+	}
+
+	if st := group.Status(); len(st) != 1 || st[0].Circuit != CircuitOpen {
+		t.Errorf("Status应报告熔断: %+v", st)
+	}
+	var buf bytes.Buffer
+	_, _ = metrics.WriteTo(&buf)
+	if !strings.Contains(buf.String(), `mgredis_circuit_state{group="defaultGroup",name="cache"} 2`) {
+		t.Errorf("指标应包含熔断状态:\n%s", buf.String())
+	}
+
+	// 半开试探失败后重新熔断
+	time.Sleep(120 * time.Millisecond)
+	if st := group.Status(); st[0].Circuit != CircuitHalfOpen {
+		t.Errorf("预期half-open，实际为%s", st[0].Circuit)
+	}
+	if err := client.Get(ctx, "k").Err(); !IsErrTimeout(err) {
+		t.Fatalf("试探请求应被放行，实际为: %v", err)
+	}
+	if err := client.Get(ctx, "k").Err(); !IsErrCircuitOpen(err) {
+		t.Fatalf("试探失败后应重新熔断，实际为: %v", err)
+	}
+
+	// 半开试探成功后恢复
+	slow.Store(false)
+	time.Sleep(120 * time.Millisecond)
+	if err := client.Get(ctx, "k").Err(); !IsErrNil(err) {
+		t.Fatalf("试探请求应成功，实际为: %v", err)
+	}
+	if st := group.Status(); st[0].Circuit != CircuitClosed {
+		t.Errorf("预期closed，实际为%s", st[0].Circuit)
+	}
+	if err := client.Get(ctx, "k").Err(); !IsErrNil(err) {
+		t.Errorf("恢复后应正常执行，实际为: %v", err)
+	}
+}
+
+// TestCircuitBreakerPipeline 测试熔断时流水线中的所有命令都返回ErrCircuitOpen
+func TestCircuitBreakerPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	var slow atomic.Bool
+	slow.Store(true)
+	s := newSlowRedis(t, &slow)
+	group := New()
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:           s.Addr(),
+		ReadTimeout:    20 * time.Millisecond,
+		MaxRetries:     -1,
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute},
+	})
+	client := group.MustGet(ctx, "cache")
+	_ = client.Get(ctx, "k").Err()
+
+	pipe := client.Pipeline()
+	a := pipe.Get(ctx, "a")
+	b := pipe.Get(ctx, "b")
+	if _, err := pipe.Exec(ctx); !IsErrCircuitOpen(err) {
+		t.Fatalf("预期ErrCircuitOpen，实际为: %v", err)
+	}
+	if !IsErrCircuitOpen(a.Err()) || !IsErrCircuitOpen(b.Err()) {
+		t.Errorf("流水线中的命令应返回ErrCircuitOpen: %v, %v", a.Err(), b.Err())
+	}
+}
+
+// TestCircuitBreakerHalfOpenCanceled 测试半开状态下调用方取消或超时的试探请求不改变熔断状态
+func TestCircuitBreakerHalfOpenCanceled(t *testing.T) {
+	ctx := context.Background()
+
+	var slow atomic.Bool
+	slow.Store(true)
+	s := newSlowRedis(t, &slow)
+	group := New()
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:           s.Addr(),
+		ReadTimeout:    20 * time.Millisecond,
+		MaxRetries:     -1,
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: 50 * time.Millisecond},
+	})
+	client := group.MustGet(ctx, "cache")
+	if err := client.Get(ctx, "k").Err(); !IsErrTimeout(err) {
+		t.Fatalf("预期超时，实际为: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	expired, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	for _, probeCtx := range []context.Context{canceled, expired} {
+		if err := client.Get(probeCtx, "k").Err(); err == nil || IsErrCircuitOpen(err) {
+			t.Fatalf("试探请求应被放行并因上下文结束失败，实际为: %v", err)
+		}
+		if st := group.Status(); st[0].Circuit != CircuitHalfOpen {
+			t.Fatalf("上下文结束的试探请求不应改变熔断状态，实际为%s", st[0].Circuit)
+		}
+	}
+
+	// 试探名额已释放，下一个请求仍可试探
+	if err := client.Get(ctx, "k").Err(); !IsErrTimeout(err) {
+		t.Fatalf("试探请求应被放行，实际为: %v", err)
+	}
+	if st := group.Status(); st[0].Circuit != CircuitOpen {
+		t.Errorf("试探失败后应重新熔断，实际为%s", st[0].Circuit)
+	}
+}
+
+// TestCircuitBreakerConfig 测试熔断器配置的默认值和JSON解析
+func TestCircuitBreakerConfig(t *testing.T) {
+	var cfg RedisConfig
+	data := `{"addr": "127.0.0.1:6379", "circuit_breaker": {"failure_threshold": 5, "open_duration": "30s"}}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		t.Fatalf("检查失败: %v", err)
+	}
+	want := CircuitBreakerConfig{FailureThreshold: 5, OpenDuration: 30 * time.Second, HalfOpenProbes: 1}
+	if cfg.CircuitBreaker != want {
+		t.Errorf("预期%+v，实际为%+v", want, cfg.CircuitBreaker)
+	}
+
+	disabled := RedisConfig{Addr: "127.0.0.1:6379"}
+	_ = disabled.CheckAndSetDefaults()
+	if disabled.CircuitBreaker.Enabled() || disabled.CircuitBreaker != (CircuitBreakerConfig{}) {
+		t.Errorf("未启用熔断时不应设置默认值: %+v", disabled.CircuitBreaker)
+	}
+}
@@ -0,0 +1,231 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrorClass 错误类别，用于统一判断错误是否可以重试
+type ErrorClass int
+
+const (
+	// ClassNone 没有错误
+	ClassNone ErrorClass = iota
+	// ClassUnknown 无法识别的错误，如WRONGTYPE等命令错误
+	ClassUnknown
+	// ClassNil 键不存在（redis.Nil），不是故障
+	ClassNil
+	// ClassTimeout 连接、读写或等待连接池超时
+	ClassTimeout
+	// ClassCanceled 上下文被取消
+	ClassCanceled
+	// ClassConnRefused 连接被拒绝，服务未启动或端口错误
+	ClassConnRefused
+	// ClassConnection 连接被重置、关闭等其他网络错误
+	ClassConnection
+	// ClassAuth 认证失败或权限不足
+	ClassAuth
+	// ClassReadOnly 向只读副本写入（READONLY），通常发生在主从切换期间
+	ClassReadOnly
+	// ClassLoading 服务正在加载数据（LOADING）
+	ClassLoading
+	// ClassOOM 内存超过maxmemory（OOM）
+	ClassOOM
+	// ClassMoved 集群槽位已迁移（MOVED）
+	ClassMoved
+	// ClassAsk 集群槽位迁移中（ASK）
+	ClassAsk
+	// ClassConfig 配置无效，如缺少地址、URL或TLS配置错误
+	ClassConfig
+	// ClassNotFound 客户端未注册
+	ClassNotFound
+	// ClassCircuitOpen 熔断器已打开，命令未发送
+	ClassCircuitOpen
+)
+
+// String 返回错误类别的名称
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassNone:
+		return "none"
+	case ClassNil:
+		return "nil"
+	case ClassTimeout:
+		return "timeout"
+	case ClassCanceled:
+		return "canceled"
+	case ClassConnRefused:
+		return "conn_refused"
+	case ClassConnection:
+		return "connection"
+	case ClassAuth:
+		return "auth"
+	case ClassReadOnly:
+		return "readonly"
+	case ClassLoading:
+		return "loading"
+	case ClassOOM:
+		return "oom"
+	case ClassMoved:
+		return "moved"
+	case ClassAsk:
+		return "ask"
+	case ClassConfig:
+		return "config"
+	case ClassNotFound:
+		return "not_found"
+	case ClassCircuitOpen:
+		return "circuit_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable 判断该类别的错误是否为暂时性故障，稍后重试可能成功
+//
+// 超时、连接错误、READONLY、LOADING、MOVED和ASK可以重试；
+// 认证失败、配置错误、OOM、客户端未注册和上下文取消重试也不会成功；
+// 熔断期间立即重试同样会失败，应等待熔断结束或改用其他实例。
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ClassTimeout, ClassConnRefused, ClassConnection,
+		ClassReadOnly, ClassLoading, ClassMoved, ClassAsk:
+		return true
+	default:
+		return false
+	}
+}
+
+// configErrors 表示配置无效的哨兵错误
+var configErrors = []error{
+	ErrNoAddr,
+	ErrNoSentinelAddrs,
+	ErrNoClusterAddrs,
+	ErrInvalidURL,
+	ErrInvalidTLSConfig,
+	ErrInvalidConfig,
+}
+
+// Classify 返回错误的类别，可用于*Error、go-redis返回的错误及其包装
+func Classify(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ClassNone
+	case IsErrNil(err):
+		return ClassNil
+	case IsErrClientNotFound(err):
+		return ClassNotFound
+	case IsErrCircuitOpen(err):
+		return ClassCircuitOpen
+	case IsErrAuthFailed(err) || isAuthError(err):
+		return ClassAuth
+	case isConfigError(err):
+		return ClassConfig
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	case IsErrTimeout(err):
+		return ClassTimeout
+	case IsErrConnRefused(err):
+		return ClassConnRefused
+	case IsErrReadOnly(err):
+		return ClassReadOnly
+	case IsErrLoading(err):
+		return ClassLoading
+	case IsErrOOM(err):
+		return ClassOOM
+	case IsErrMoved(err):
+		return ClassMoved
+	case IsErrAsk(err):
+		return ClassAsk
+	case isConnectionError(err):
+		return ClassConnection
+	default:
+		return ClassUnknown
+	}
+}
+
+// IsRetryable 判断错误是否为暂时性故障，等价于Classify(err).Retryable()
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// IsErrNil 判断是否为键不存在（redis.Nil）
+func IsErrNil(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
+
+// errPoolTimeout go-redis等待连接池超时的错误信息，该错误未导出
+const errPoolTimeout = "redis: connection pool timeout"
+
+// IsErrTimeout 判断是否为超时错误，包括连接、读写超时、上下文超时和等待连接池超时
+func IsErrTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return strings.Contains(err.Error(), errPoolTimeout)
+}
+
+// IsErrConnRefused 判断是否为连接被拒绝错误
+func IsErrConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// IsErrReadOnly 判断是否为向只读副本写入的错误（READONLY）
+func IsErrReadOnly(err error) bool {
+	return redis.HasErrorPrefix(err, "READONLY ")
+}
+
+// IsErrLoading 判断是否为服务正在加载数据的错误（LOADING）
+func IsErrLoading(err error) bool {
+	return redis.HasErrorPrefix(err, "LOADING ")
+}
+
+// IsErrOOM 判断是否为内存超过maxmemory的错误（OOM）
+func IsErrOOM(err error) bool {
+	return redis.HasErrorPrefix(err, "OOM ")
+}
+
+// IsErrMoved 判断是否为集群槽位已迁移的错误（MOVED）
+func IsErrMoved(err error) bool {
+	return redis.HasErrorPrefix(err, "MOVED ")
+}
+
+// IsErrAsk 判断是否为集群槽位迁移中的错误（ASK）
+func IsErrAsk(err error) bool {
+	return redis.HasErrorPrefix(err, "ASK ")
+}
+
+// isConfigError 判断是否为配置无效错误
+func isConfigError(err error) bool {
+	for _, target := range configErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConnectionError 判断是否为连接被重置、关闭等网络错误
+func isConnectionError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, net.ErrClosed) || errors.Is(err, redis.ErrClosed) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
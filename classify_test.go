@@ -0,0 +1,92 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TestClassify 测试错误分类
+func TestClassify(t *testing.T) {
+	ctx := context.Background()
+
+	// 对GET返回以键名对应的Redis错误
+	replies := map[string]respError{
+		"readonly":  "READONLY You can't write against a read only replica.",
+		"loading":   "LOADING Redis is loading the dataset in memory",
+		"oom":       "OOM command not allowed when used memory > 'maxmemory'.",
+		"moved":     "MOVED 3999 127.0.0.1:6381",
+		"ask":       "ASK 3999 127.0.0.1:6381",
+		"noauth":    "NOAUTH Authentication required.",
+		"wrongtype": "WRONGTYPE Operation against a key holding the wrong kind of value",
+	}
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if !strings.EqualFold(args[0], "GET") {
+			return nil, false
+		}
+		if reply, ok := replies[args[1]]; ok {
+			return reply, true
+		}
+		return nil, true
+	})
+
+	group := New()
+	defer group.Close(ctx)
+	_, _ = group.Register(ctx, "fake", RedisConfig{Addr: s.Addr(), MaxRetries: -1})
+	_, _ = group.Register(ctx, "refused", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: -1})
+	_, _ = group.Register(ctx, "empty", RedisConfig{})
+	client := group.MustGet(ctx, "fake")
+
+	_, refusedErr := group.Get(ctx, "refused")
+	_, emptyErr := group.Get(ctx, "empty")
+	_, notFoundErr := group.Get(ctx, "missing")
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Nanosecond)
+	defer cancel()
+	<-timeoutCtx.Done()
+	canceledCtx, cancel2 := context.WithCancel(ctx)
+	cancel2()
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ClassNone},
+		{"redis.Nil", client.Get(ctx, "none").Err(), ClassNil},
+		{"READONLY", client.Get(ctx, "readonly").Err(), ClassReadOnly},
+		{"LOADING", client.Get(ctx, "loading").Err(), ClassLoading},
+		{"OOM", client.Get(ctx, "oom").Err(), ClassOOM},
+		{"MOVED", client.Get(ctx, "moved").Err(), ClassMoved},
+		{"ASK", client.Get(ctx, "ask").Err(), ClassAsk},
+		{"NOAUTH", client.Get(ctx, "noauth").Err(), ClassAuth},
+		{"WRONGTYPE", client.Get(ctx, "wrongtype").Err(), ClassUnknown},
+		{"包装后的READONLY", fmt.Errorf("write: %w", client.Get(ctx, "readonly").Err()), ClassReadOnly},
+		{"连接被拒绝", refusedErr, ClassConnRefused},
+		{"上下文超时", client.Get(timeoutCtx, "k").Err(), ClassTimeout},
+		{"上下文取消", client.Get(canceledCtx, "k").Err(), ClassCanceled},
+		{"配置无效", emptyErr, ClassConfig},
+		{"客户端不存在", notFoundErr, ClassNotFound},
+		{"认证失败", &Error{Op: OpPing, Kind: ErrAuthFailed, Err: errors.New("WRONGPASS")}, ClassAuth},
+		{"客户端已关闭", redis.ErrClosed, ClassConnection},
+		{"其他错误", errors.New("boom"), ClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("预期%s，实际为%s: %v", tt.want, got, tt.err)
+			}
+		})
+	}
+
+	if !IsRetryable(refusedErr) || !IsRetryable(client.Get(ctx, "loading").Err()) {
+		t.Error("连接被拒绝和LOADING应可重试")
+	}
+	if IsRetryable(emptyErr) || IsRetryable(client.Get(ctx, "oom").Err()) || IsRetryable(nil) {
+		t.Error("配置错误、OOM和nil不应重试")
+	}
+}
@@ -0,0 +1,185 @@
+package mgredis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/qq1060656096/bizutil/registry"
+	"github.com/redis/go-redis/v9"
+)
+
+// ClusterConfig Redis Cluster客户端配置
+type ClusterConfig struct {
+	// Name 资源描述名称，用于日志等
+	Name string `json:"name"`
+
+	// Addrs 集群种子节点地址列表，格式: ["host:port"]
+	Addrs []string `json:"addrs"`
+
+	// Username ACL用户名，为空表示使用default用户
+	Username string `json:"username"`
+
+	// Password 密码，为空表示无密码
+	Password string `json:"password"`
+
+	// TLS TLS连接配置，所有节点共用，未设置ServerName时使用各节点地址中的主机名
+	TLS TLSConfig `json:"tls"`
+
+	// MaxRedirects 遇到MOVED/ASK时的最大重定向次数，默认为3
+	MaxRedirects int `json:"max_redirects"`
+
+	// ReadOnly 为true时允许在从节点上执行只读命令
+	ReadOnly bool `json:"read_only"`
+
+	// RouteByLatency 为true时将只读命令路由到延迟最低的节点，隐含ReadOnly
+	RouteByLatency bool `json:"route_by_latency"`
+
+	// RouteRandomly 为true时将只读命令随机路由到任意节点，隐含ReadOnly
+	RouteRandomly bool `json:"route_randomly"`
+
+	// PoolSize 每个节点的最大连接数，默认为10
+	PoolSize int `json:"pool_size"`
+
+	// MinIdleConns 每个节点的最小空闲连接数，默认为2
+	MinIdleConns int `json:"min_idle_conns"`
+
+	// DialTimeout 连接超时时间，默认为5秒
+	DialTimeout time.Duration `json:"dial_timeout"`
+
+	// ReadTimeout 读取超时时间，默认为3秒
+	ReadTimeout time.Duration `json:"read_timeout"`
+
+	// WriteTimeout 写入超时时间，默认为3秒
+	WriteTimeout time.Duration `json:"write_timeout"`
+
+	// MaxRetries 最大重试次数，默认为3
+	MaxRetries int `json:"max_retries"`
+
+	// PoolTimeout 从连接池获取连接的超时时间，默认为4秒
+	PoolTimeout time.Duration `json:"pool_timeout"`
+
+	// IdleTimeout 空闲连接超时时间，默认为5分钟
+	IdleTimeout time.Duration `json:"idle_timeout"`
+}
+
+// CheckAndSetDefaults 检查配置并设置默认值
+func (cfg *ClusterConfig) CheckAndSetDefaults() error {
+	if len(cfg.Addrs) == 0 {
+		return ErrNoClusterAddrs
+	}
+
+	// 设置默认值
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 3
+	}
+
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+
+	if cfg.MinIdleConns <= 0 {
+		cfg.MinIdleConns = 2
+	}
+
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 3 * time.Second
+	}
+
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 3 * time.Second
+	}
+
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+
+	if cfg.PoolTimeout <= 0 {
+		cfg.PoolTimeout = 4 * time.Second
+	}
+
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 5 * time.Minute
+	}
+
+	if err := cfg.TLS.CheckAndSetDefaults(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// clusterOpener 创建Redis Cluster客户端连接
+func clusterOpener(ctx context.Context, cfg ClusterConfig) (*redis.ClusterClient, error) {
+	// 检查并设置默认值
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, err
+	}
+
+	// 创建TLS配置，ServerName为空时由tls.DialWithDialer根据每个节点的地址推导
+	tlsConfig, err := cfg.TLS.Build("")
+	if err != nil {
+		return nil, &Error{Op: OpOpen, Addr: strings.Join(cfg.Addrs, ","), Err: err}
+	}
+
+	// 创建集群客户端
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:           cfg.Addrs,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		TLSConfig:       tlsConfig,
+		MaxRedirects:    cfg.MaxRedirects,
+		ReadOnly:        cfg.ReadOnly,
+		RouteByLatency:  cfg.RouteByLatency,
+		RouteRandomly:   cfg.RouteRandomly,
+		PoolSize:        cfg.PoolSize,
+		MinIdleConns:    cfg.MinIdleConns,
+		DialTimeout:     cfg.DialTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		MaxRetries:      cfg.MaxRetries,
+		PoolTimeout:     cfg.PoolTimeout,
+		ConnMaxIdleTime: cfg.IdleTimeout,
+	})
+
+	// 使用超时上下文进行Ping测试
+	ctx2, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx2).Err(); err != nil {
+		_ = client.Close()
+		return nil, &Error{Op: OpPing, Addr: strings.Join(cfg.Addrs, ","), Kind: ErrPingFailed, Err: err}
+	}
+
+	return client, nil
+}
+
+// clusterCloser 关闭Redis Cluster客户端连接
+func clusterCloser(ctx context.Context, client *redis.ClusterClient) error {
+	if client == nil {
+		return nil
+	}
+	return client.Close()
+}
+
+// ClusterGroup 是Redis Cluster的单一组管理（key => cluster client）
+type ClusterGroup registry.Group[ClusterConfig, *redis.ClusterClient]
+
+// ClusterManager 是Redis Cluster的多组管理
+type ClusterManager registry.Manager[ClusterConfig, *redis.ClusterClient]
+
+// NewCluster 创建单组Redis Cluster客户端管理器
+// 与New相同，支持惰性初始化、创建时Ping测试和安全关闭所有资源
+func NewCluster() ClusterGroup {
+	return registry.New[ClusterConfig, *redis.ClusterClient](clusterOpener, clusterCloser)
+}
+
+// NewClusterManager 创建多组Redis Cluster客户端管理器
+// 与NewManager相同，每个组可以包含多个命名的Redis Cluster客户端实例
+func NewClusterManager() ClusterManager {
+	return registry.NewManager[ClusterConfig, *redis.ClusterClient](clusterOpener, clusterCloser)
+}
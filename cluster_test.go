@@ -0,0 +1,232 @@
+package mgredis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFakeCluster 启动一个单节点负责全部槽位的fake集群
+func newFakeCluster(t *testing.T) *fakeRedis {
+	var node *fakeRedis
+	node = newFakeRedis(t, fakeClusterHandler(&node, nil))
+	return node
+}
+
+// fakeClusterHandler 以*node作为唯一节点回复CLUSTER SLOTS，其余命令先交给next处理
+func fakeClusterHandler(node **fakeRedis, next fakeHandler) fakeHandler {
+	return func(args []string) (interface{}, bool) {
+		if next != nil {
+			if reply, ok := next(args); ok {
+				return reply, true
+			}
+		}
+		switch strings.ToUpper(args[0]) {
+		case "CLUSTER":
+			if len(args) > 1 && strings.EqualFold(args[1], "SLOTS") {
+				port, _ := strconv.Atoi((*node).Port())
+				return []interface{}{
+					[]interface{}{int64(0), int64(16383), []interface{}{(*node).Host(), int64(port), "node-1"}},
+				}, true
+			}
+		case "GET":
+			return "cluster", true
+		}
+		return nil, false
+	}
+}
+
+// TestClusterConfig 测试集群配置
+func TestClusterConfig(t *testing.T) {
+	t.Run("缺少种子节点", func(t *testing.T) {
+		cfg := ClusterConfig{}
+		if err := cfg.CheckAndSetDefaults(); !IsErrNoClusterAddrs(err) {
+			t.Errorf("预期ErrNoClusterAddrs错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("无效的TLS配置", func(t *testing.T) {
+		cfg := ClusterConfig{Addrs: []string{"127.0.0.1:7000"}, TLS: TLSConfig{Enabled: true, MinVersion: "2.0"}}
+		if err := cfg.CheckAndSetDefaults(); !IsErrInvalidTLSConfig(err) {
+			t.Errorf("预期ErrInvalidTLSConfig错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("配置默认值设置", func(t *testing.T) {
+		cfg := ClusterConfig{Addrs: []string{"127.0.0.1:7000"}}
+		if err := cfg.CheckAndSetDefaults(); err != nil {
+			t.Fatalf("CheckAndSetDefaults失败: %v", err)
+		}
+		if cfg.MaxRedirects != 3 {
+			t.Errorf("预期MaxRedirects为3，实际为%d", cfg.MaxRedirects)
+		}
+		if cfg.PoolSize != 10 {
+			t.Errorf("预期PoolSize为10，实际为%d", cfg.PoolSize)
+		}
+		if cfg.IdleTimeout != 5*time.Minute {
+			t.Errorf("预期IdleTimeout为5m，实际为%v", cfg.IdleTimeout)
+		}
+	})
+}
+
+// TestNewCluster 测试集群单组管理器
+func TestNewCluster(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("注册和获取集群客户端", func(t *testing.T) {
+		node := newFakeCluster(t)
+
+		group := NewCluster()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "cluster", ClusterConfig{
+			Addrs:          []string{node.Addr()},
+			RouteByLatency: true,
+			DialTimeout:    time.Second,
+		})
+
+		client, err := group.Get(ctx, "cluster")
+		if err != nil {
+			t.Fatalf("获取集群客户端失败: %v", err)
+		}
+
+		client2, _ := group.Get(ctx, "cluster")
+		if client != client2 {
+			t.Error("预期获取同一个客户端实例")
+		}
+
+		val, err := client.Get(ctx, "key").Result()
+		if err != nil {
+			t.Fatalf("GET失败: %v", err)
+		}
+		if val != "cluster" {
+			t.Errorf("预期cluster，实际为%s", val)
+		}
+	})
+
+	t.Run("无效配置在Get时返回错误", func(t *testing.T) {
+		group := NewCluster()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "invalid", ClusterConfig{})
+		if _, err := group.Get(ctx, "invalid"); !IsErrNoClusterAddrs(err) {
+			t.Errorf("预期ErrNoClusterAddrs错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("节点不可达", func(t *testing.T) {
+		_, err := clusterOpener(ctx, ClusterConfig{
+			Addrs:       []string{"127.0.0.1:1"},
+			DialTimeout: 200 * time.Millisecond,
+			MaxRetries:  1,
+		})
+		if !IsErrPingFailed(err) {
+			t.Errorf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+	})
+}
+
+// TestClusterOpenerTLS 测试集群客户端通过TLS和ACL用户名连接
+func TestClusterOpenerTLS(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCert(t, nil, true, 0)
+	server := newTestCert(t, ca, false, x509.ExtKeyUsageServerAuth)
+
+	cert, err := tls.X509KeyPair([]byte(server.certPEM), []byte(server.keyPEM))
+	if err != nil {
+		t.Fatalf("加载服务端证书失败: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("启动TLS监听失败: %v", err)
+	}
+
+	var (
+		mu   sync.Mutex
+		auth []string
+		node *fakeRedis
+	)
+	node = startFakeRedis(t, ln, fakeClusterHandler(&node, func(args []string) (interface{}, bool) {
+		if strings.EqualFold(args[0], "AUTH") {
+			mu.Lock()
+			auth = append([]string(nil), args[1:]...)
+			mu.Unlock()
+		}
+		return nil, false
+	}))
+
+	t.Run("使用CA校验节点证书", func(t *testing.T) {
+		client, err := clusterOpener(ctx, ClusterConfig{
+			Addrs:    []string{node.Addr()},
+			Username: "app",
+			Password: "secret",
+			TLS:      TLSConfig{Enabled: true, CAPEM: ca.certPEM},
+		})
+		if err != nil {
+			t.Fatalf("TLS连接失败: %v", err)
+		}
+		defer client.Close()
+
+		if val := client.Get(ctx, "key").Val(); val != "cluster" {
+			t.Errorf("预期cluster，实际为%q", val)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(auth) != 2 || auth[0] != "app" || auth[1] != "secret" {
+			t.Errorf("预期使用用户名app认证，实际为%v", auth)
+		}
+	})
+
+	t.Run("未知CA连接失败", func(t *testing.T) {
+		_, err := clusterOpener(ctx, ClusterConfig{
+			Addrs:      []string{node.Addr()},
+			MaxRetries: 1,
+			TLS:        TLSConfig{Enabled: true},
+		})
+		if !IsErrPingFailed(err) {
+			t.Errorf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("无效CA", func(t *testing.T) {
+		_, err := clusterOpener(ctx, ClusterConfig{
+			Addrs: []string{node.Addr()},
+			TLS:   TLSConfig{Enabled: true, CAPEM: "not a pem"},
+		})
+		if !IsErrInvalidTLSConfig(err) {
+			t.Errorf("预期ErrInvalidTLSConfig错误，实际得到: %v", err)
+		}
+	})
+}
+
+// TestNewClusterManager 测试集群多组管理器
+func TestNewClusterManager(t *testing.T) {
+	ctx := context.Background()
+	node := newFakeCluster(t)
+
+	manager := NewClusterManager()
+	defer manager.Close(ctx)
+
+	manager.AddGroup("orders")
+	group, err := manager.Group("orders")
+	if err != nil {
+		t.Fatalf("获取组失败: %v", err)
+	}
+
+	_, _ = group.Register(ctx, "primary", ClusterConfig{
+		Addrs:       []string{node.Addr()},
+		DialTimeout: time.Second,
+	})
+	if _, err := group.Get(ctx, "primary"); err != nil {
+		t.Fatalf("获取集群客户端失败: %v", err)
+	}
+
+	if errs := manager.Close(ctx); len(errs) > 0 {
+		t.Errorf("关闭失败: %v", errs)
+	}
+}
@@ -1,6 +1,10 @@
 package mgredis
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -9,12 +13,36 @@ type RedisConfig struct {
 	// Name 资源描述名称，用于日志等
 	Name string `json:"name"`
 
-	// Addr Redis服务器地址，格式: "host:port"
+	// Network 网络类型，"tcp"或"unix"，默认为tcp
+	Network string `json:"network"`
+
+	// Addr Redis服务器地址，格式: "host:port"，unix网络时为socket文件路径
 	Addr string `json:"addr"`
 
+	// FailoverAddrs 备用地址列表，Addr不可用时按顺序切换，见Failover，不支持Sentinel模式
+	FailoverAddrs []string `json:"failover_addrs"`
+
+	// Failover 故障切换策略，仅在设置了FailoverAddrs时生效
+	Failover FailoverConfig `json:"failover"`
+
+	// Replicas 从节点地址列表，通过Group.GetReadWrite获取的读写分离客户端将只读命令路由到从节点，不支持Sentinel模式
+	Replicas []string `json:"replicas"`
+
+	// ReplicaSelection 从节点选择策略，"round_robin"（默认）或"least_latency"
+	ReplicaSelection string `json:"replica_selection"`
+
+	// Username ACL用户名，为空表示使用default用户
+	Username string `json:"username"`
+
 	// Password 密码，为空表示无密码
 	Password string `json:"password"`
 
+	// CredentialsProvider 凭据提供者，非nil时每次建立新连接都会调用以获取用户名和密码，
+	// 优先于Username和Password，可用于从密钥管理服务获取并轮换密码。
+	// Sentinel模式下仅用于主从节点的连接，Sentinel节点使用SentinelPassword。
+	// 返回的错误归类为ErrAuthFailed
+	CredentialsProvider CredentialsProvider `json:"-"`
+
 	// DB 数据库索引，默认为0
 	DB int `json:"db"`
 
@@ -41,15 +69,68 @@ type RedisConfig struct {
 
 	// IdleTimeout 空闲连接超时时间，默认为5分钟
 	IdleTimeout time.Duration `json:"idle_timeout"`
+
+	// SlowThreshold 慢命令阈值，执行时间超过该值的命令会通过WithLogger设置的日志记录，为0表示不记录
+	SlowThreshold time.Duration `json:"slow_threshold"`
+
+	// MasterName Sentinel主节点名称，非空时启用Sentinel模式，此时忽略Addr
+	MasterName string `json:"master_name"`
+
+	// SentinelAddrs Sentinel节点地址列表，格式: ["host:port"]，Sentinel模式下必填
+	SentinelAddrs []string `json:"sentinel_addrs"`
+
+	// SentinelPassword Sentinel节点的密码，为空表示无密码
+	SentinelPassword string `json:"sentinel_password"`
+
+	// ReplicaOnly 为true时只将命令路由到从节点（只读场景）
+	ReplicaOnly bool `json:"replica_only"`
+
+	// TLS TLS连接配置
+	TLS TLSConfig `json:"tls"`
+
+	// CircuitBreaker 熔断器配置，FailureThreshold为0时不启用
+	CircuitBreaker CircuitBreakerConfig `json:"circuit_breaker"`
+}
+
+// CredentialsProvider 在建立连接时返回认证使用的用户名和密码
+type CredentialsProvider func(ctx context.Context) (username, password string, err error)
+
+// IsSentinel 是否为Sentinel模式
+func (cfg *RedisConfig) IsSentinel() bool {
+	return cfg.MasterName != ""
 }
 
 // CheckAndSetDefaults 检查配置并设置默认值
 func (cfg *RedisConfig) CheckAndSetDefaults() error {
-	if cfg.Addr == "" {
+	if cfg.IsSentinel() {
+		if len(cfg.SentinelAddrs) == 0 {
+			return ErrNoSentinelAddrs
+		}
+		if len(cfg.FailoverAddrs) > 0 {
+			return fmt.Errorf("%w: failover_addrs is not supported in sentinel mode", ErrInvalidConfig)
+		}
+		if len(cfg.Replicas) > 0 {
+			return fmt.Errorf("%w: replicas is not supported in sentinel mode, use replica_only", ErrInvalidConfig)
+		}
+	} else if cfg.Addr == "" {
 		return ErrNoAddr
 	}
 
+	if len(cfg.FailoverAddrs) > 0 {
+		if err := cfg.Failover.CheckAndSetDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if err := checkReplicaSelection(cfg.ReplicaSelection); err != nil {
+		return err
+	}
+
 	// 设置默认值
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+
 	if cfg.PoolSize <= 0 {
 		cfg.PoolSize = 10
 	}
@@ -82,5 +163,63 @@ func (cfg *RedisConfig) CheckAndSetDefaults() error {
 		cfg.IdleTimeout = 5 * time.Minute
 	}
 
+	if err := cfg.TLS.CheckAndSetDefaults(); err != nil {
+		return err
+	}
+
+	if err := cfg.CircuitBreaker.CheckAndSetDefaults(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalJSON 解析JSON配置，时间字段同时支持"3s"格式的字符串和表示秒数的整数
+// 整数按秒解析，与连接URL和环境变量保持一致
+func (cfg *RedisConfig) UnmarshalJSON(data []byte) error {
+	type plain RedisConfig
+	aux := struct {
+		*plain
+		DialTimeout   *jsonDuration `json:"dial_timeout"`
+		ReadTimeout   *jsonDuration `json:"read_timeout"`
+		WriteTimeout  *jsonDuration `json:"write_timeout"`
+		PoolTimeout   *jsonDuration `json:"pool_timeout"`
+		IdleTimeout   *jsonDuration `json:"idle_timeout"`
+		SlowThreshold *jsonDuration `json:"slow_threshold"`
+	}{
+		plain:         (*plain)(cfg),
+		DialTimeout:   (*jsonDuration)(&cfg.DialTimeout),
+		ReadTimeout:   (*jsonDuration)(&cfg.ReadTimeout),
+		WriteTimeout:  (*jsonDuration)(&cfg.WriteTimeout),
+		PoolTimeout:   (*jsonDuration)(&cfg.PoolTimeout),
+		IdleTimeout:   (*jsonDuration)(&cfg.IdleTimeout),
+		SlowThreshold: (*jsonDuration)(&cfg.SlowThreshold),
+	}
+	return json.Unmarshal(data, &aux)
+}
+
+// jsonDuration 支持字符串格式的时长
+type jsonDuration time.Duration
+
+// UnmarshalJSON 解析"3s"格式的字符串或表示秒数的整数
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", s, err)
+		}
+		*d = jsonDuration(v)
+		return nil
+	}
+
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = jsonDuration(time.Duration(n) * time.Second)
 	return nil
 }
@@ -0,0 +1,194 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFakeAuthRedis 启动一个要求ACL认证的fakeRedis，返回可在运行时修改的密码
+func newFakeAuthRedis(t *testing.T, username string, password *atomic.Value) *fakeRedis {
+	authed := func(args []string) bool {
+		if len(args) == 2 {
+			return username == "default" && args[1] == password.Load().(string)
+		}
+		return len(args) == 3 && args[1] == username && args[2] == password.Load().(string)
+	}
+
+	// fakeRedis不保存连接状态，这里只校验AUTH命令本身
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if !strings.EqualFold(args[0], "AUTH") {
+			return nil, false
+		}
+		if !authed(args) {
+			return respError("WRONGPASS invalid username-password pair or user is disabled."), true
+		}
+		return respSimple("OK"), true
+	})
+}
+
+// TestOpenerAuth 测试ACL用户名与认证失败
+func TestOpenerAuth(t *testing.T) {
+	ctx := context.Background()
+	var password atomic.Value
+	password.Store("s3cret")
+	srv := newFakeAuthRedis(t, "app", &password)
+
+	t.Run("用户名和密码正确", func(t *testing.T) {
+		client, err := opener(ctx, RedisConfig{Addr: srv.Addr(), Username: "app", Password: "s3cret"})
+		if err != nil {
+			t.Fatalf("认证失败: %v", err)
+		}
+		_ = client.Close()
+	})
+
+	t.Run("密码错误", func(t *testing.T) {
+		_, err := opener(ctx, RedisConfig{Addr: srv.Addr(), Username: "app", Password: "wrong"})
+		if !IsErrAuthFailed(err) {
+			t.Errorf("预期ErrAuthFailed错误，实际得到: %v", err)
+		}
+		if IsErrPingFailed(err) {
+			t.Errorf("认证失败不应判断为ErrPingFailed: %v", err)
+		}
+	})
+
+	t.Run("主机不可达不是认证失败", func(t *testing.T) {
+		_, err := opener(ctx, RedisConfig{Addr: "127.0.0.1:1", Password: "s3cret", MaxRetries: 1})
+		if IsErrAuthFailed(err) || !IsErrPingFailed(err) {
+			t.Errorf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+	})
+}
+
+// TestCredentialsProvider 测试凭据提供者
+func TestCredentialsProvider(t *testing.T) {
+	ctx := context.Background()
+	var password atomic.Value
+	password.Store("v1")
+	srv := newFakeAuthRedis(t, "app", &password)
+
+	var calls atomic.Int32
+	var secret atomic.Value
+	secret.Store("v1")
+	provider := func(ctx context.Context) (string, string, error) {
+		calls.Add(1)
+		return "app", secret.Load().(string), nil
+	}
+
+	group := New()
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:                srv.Addr(),
+		Password:            "ignored",
+		MinIdleConns:        1,
+		PoolSize:            1,
+		CredentialsProvider: provider,
+	})
+
+	client, err := group.Get(ctx, "cache")
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	if calls.Load() == 0 {
+		t.Fatal("预期建立连接时调用凭据提供者")
+	}
+
+	// 轮换密码后断开连接，重新建立的连接应使用新密码，无需重新注册
+	password.Store("v2")
+	secret.Store("v2")
+	srv.DropConns()
+	before := calls.Load()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err = client.Ping(ctx).Err()
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("轮换密码后Ping失败: %v", err)
+	}
+	if calls.Load() <= before {
+		t.Error("预期重新建立连接时再次调用凭据提供者")
+	}
+
+	t.Run("凭据提供者返回错误", func(t *testing.T) {
+		failing := func(ctx context.Context) (string, string, error) {
+			return "", "", errors.New("secret store unavailable")
+		}
+
+		_, err := opener(ctx, RedisConfig{Addr: srv.Addr(), CredentialsProvider: failing, MaxRetries: 1})
+		if !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("预期ErrAuthFailed错误，实际得到: %v", err)
+		}
+		if errors.Is(err, ErrPingFailed) {
+			t.Errorf("凭据提供者错误不应判断为ErrPingFailed: %v", err)
+		}
+
+		sentinel := newFakeSentinel(t, "mymaster", srv, nil)
+		_, err = opener(ctx, RedisConfig{
+			MasterName:          "mymaster",
+			SentinelAddrs:       []string{sentinel.Addr()},
+			CredentialsProvider: failing,
+			MaxRetries:          1,
+		})
+		if !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("Sentinel模式预期ErrAuthFailed错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("Sentinel模式每次建立连接时获取凭据", func(t *testing.T) {
+		password.Store("v1")
+		secret.Store("v1")
+		sentinel := newFakeSentinel(t, "mymaster", srv, nil)
+		var sentinelCalls atomic.Int32
+
+		client, err := opener(ctx, RedisConfig{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{sentinel.Addr()},
+			PoolSize:      1,
+			MinIdleConns:  1,
+			CredentialsProvider: func(ctx context.Context) (string, string, error) {
+				sentinelCalls.Add(1)
+				return "app", secret.Load().(string), nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("创建客户端失败: %v", err)
+		}
+		defer client.Close()
+		if sentinelCalls.Load() == 0 {
+			t.Fatal("预期建立连接时调用凭据提供者")
+		}
+
+		// 轮换密码后断开主节点连接，重新建立的连接应使用新密码
+		password.Store("v3")
+		secret.Store("v3")
+		srv.DropConns()
+		before := sentinelCalls.Load()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			err = client.Ping(ctx).Err()
+			if err == nil || time.Now().After(deadline) {
+				break
+			}
+		}
+		if err != nil {
+			t.Fatalf("轮换密码后Ping失败: %v", err)
+		}
+		if sentinelCalls.Load() <= before {
+			t.Error("预期重新建立连接时再次调用凭据提供者")
+		}
+		for _, cmd := range sentinel.Commands() {
+			if cmd == "AUTH" {
+				t.Error("Sentinel节点的连接不应使用凭据提供者认证")
+			}
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix 环境变量的默认前缀
+const DefaultEnvPrefix = "MGREDIS"
+
+// envSetter 将环境变量的值写入配置
+type envSetter func(cfg *RedisConfig, val string) error
+
+// envFields 环境变量字段名 => 写入函数，字段名与json标签一致（大写）
+var envFields = map[string]envSetter{
+	"URL":                               func(cfg *RedisConfig, val string) error { return cfg.FromURL(val) },
+	"NAME":                              envString(func(cfg *RedisConfig) *string { return &cfg.Name }),
+	"NETWORK":                           envString(func(cfg *RedisConfig) *string { return &cfg.Network }),
+	"ADDR":                              envString(func(cfg *RedisConfig) *string { return &cfg.Addr }),
+	"USERNAME":                          envString(func(cfg *RedisConfig) *string { return &cfg.Username }),
+	"PASSWORD":                          envString(func(cfg *RedisConfig) *string { return &cfg.Password }),
+	"DB":                                envInt(func(cfg *RedisConfig) *int { return &cfg.DB }),
+	"POOL_SIZE":                         envInt(func(cfg *RedisConfig) *int { return &cfg.PoolSize }),
+	"MIN_IDLE_CONNS":                    envInt(func(cfg *RedisConfig) *int { return &cfg.MinIdleConns }),
+	"MAX_RETRIES":                       envInt(func(cfg *RedisConfig) *int { return &cfg.MaxRetries }),
+	"DIAL_TIMEOUT":                      envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.DialTimeout }),
+	"READ_TIMEOUT":                      envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.ReadTimeout }),
+	"WRITE_TIMEOUT":                     envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.WriteTimeout }),
+	"POOL_TIMEOUT":                      envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.PoolTimeout }),
+	"IDLE_TIMEOUT":                      envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.IdleTimeout }),
+	"SLOW_THRESHOLD":                    envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.SlowThreshold }),
+	"MASTER_NAME":                       envString(func(cfg *RedisConfig) *string { return &cfg.MasterName }),
+	"SENTINEL_ADDRS":                    envStrings(func(cfg *RedisConfig) *[]string { return &cfg.SentinelAddrs }),
+	"SENTINEL_PASSWORD":                 envString(func(cfg *RedisConfig) *string { return &cfg.SentinelPassword }),
+	"REPLICA_ONLY":                      envBool(func(cfg *RedisConfig) *bool { return &cfg.ReplicaOnly }),
+	"TLS_ENABLED":                       envBool(func(cfg *RedisConfig) *bool { return &cfg.TLS.Enabled }),
+	"TLS_CA_FILE":                       envString(func(cfg *RedisConfig) *string { return &cfg.TLS.CAFile }),
+	"TLS_CA_PEM":                        envString(func(cfg *RedisConfig) *string { return &cfg.TLS.CAPEM }),
+	"TLS_CERT_FILE":                     envString(func(cfg *RedisConfig) *string { return &cfg.TLS.CertFile }),
+	"TLS_KEY_FILE":                      envString(func(cfg *RedisConfig) *string { return &cfg.TLS.KeyFile }),
+	"TLS_CERT_PEM":                      envString(func(cfg *RedisConfig) *string { return &cfg.TLS.CertPEM }),
+	"TLS_KEY_PEM":                       envString(func(cfg *RedisConfig) *string { return &cfg.TLS.KeyPEM }),
+	"TLS_SERVER_NAME":                   envString(func(cfg *RedisConfig) *string { return &cfg.TLS.ServerName }),
+	"TLS_MIN_VERSION":                   envString(func(cfg *RedisConfig) *string { return &cfg.TLS.MinVersion }),
+	"TLS_INSECURE_SKIP_VERIFY":          envBool(func(cfg *RedisConfig) *bool { return &cfg.TLS.InsecureSkipVerify }),
+	"FAILOVER_ADDRS":                    envStrings(func(cfg *RedisConfig) *[]string { return &cfg.FailoverAddrs }),
+	"FAILOVER_FAIL_AFTER":               envInt(func(cfg *RedisConfig) *int { return &cfg.Failover.FailAfter }),
+	"FAILOVER_CHECK_INTERVAL":           envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.Failover.CheckInterval }),
+	"FAILOVER_FAILBACK":                 envBool(func(cfg *RedisConfig) *bool { return &cfg.Failover.Failback }),
+	"REPLICAS":                          envStrings(func(cfg *RedisConfig) *[]string { return &cfg.Replicas }),
+	"REPLICA_SELECTION":                 envString(func(cfg *RedisConfig) *string { return &cfg.ReplicaSelection }),
+	"CIRCUIT_BREAKER_FAILURE_THRESHOLD": envInt(func(cfg *RedisConfig) *int { return &cfg.CircuitBreaker.FailureThreshold }),
+	"CIRCUIT_BREAKER_OPEN_DURATION":     envDuration(func(cfg *RedisConfig) *time.Duration { return &cfg.CircuitBreaker.OpenDuration }),
+	"CIRCUIT_BREAKER_HALF_OPEN_PROBES":  envInt(func(cfg *RedisConfig) *int { return &cfg.CircuitBreaker.HalfOpenProbes }),
+}
+
+// envFieldNames 按长度降序排列的字段名，优先匹配较长的后缀（如MASTER_NAME优先于NAME）
+var envFieldNames = func() []string {
+	names := make([]string, 0, len(envFields))
+	for name := range envFields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}()
+
+// ParseEnv 从环境变量列表（格式同os.Environ）解析管理器配置
+//
+// 变量名格式为 <PREFIX>_<GROUP>_<NAME>_<FIELD>，例如:
+//
+//	MGREDIS_CACHE_PRIMARY_ADDR=127.0.0.1:6379
+//	MGREDIS_CACHE_PRIMARY_POOL_SIZE=20
+//	MGREDIS_CACHE_PRIMARY_READ_TIMEOUT=1s
+//
+// FIELD为RedisConfig的json标签的大写形式，TLS字段使用TLS_前缀（如TLS_CA_FILE），
+// SENTINEL_ADDRS使用逗号分隔，URL字段会先按连接URL解析再应用其他字段。
+// GROUP为第一个下划线之前的部分，其余为NAME，二者均转换为小写，因此组名不能包含下划线。
+// 无法识别FIELD的变量会被忽略，已识别字段中所有无法解析的值会一次性返回。
+func ParseEnv(prefix string, environ []string) (ManagerConfig, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.ToUpper(prefix) + "_"
+
+	type entry struct {
+		key, field, val string
+	}
+	entries := make(map[string]map[string][]entry)
+	var errs []error
+	for _, kv := range environ {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(strings.ToUpper(key), prefix) {
+			continue
+		}
+
+		groupName, name, field, ok := splitEnvKey(strings.ToUpper(key[len(prefix):]))
+		if !ok {
+			// 前缀相同的其他变量（如MGREDIS_VERSION）或不支持的字段直接忽略
+			continue
+		}
+		if entries[groupName] == nil {
+			entries[groupName] = make(map[string][]entry)
+		}
+		entries[groupName][name] = append(entries[groupName][name], entry{key: key, field: field, val: val})
+	}
+
+	cfg := ManagerConfig{Groups: make(map[string]GroupConfig, len(entries))}
+	for _, groupName := range sortedKeys(entries) {
+		gc := GroupConfig{Instances: make(map[string]RedisConfig)}
+		for _, name := range sortedKeys(entries[groupName]) {
+			list := entries[groupName][name]
+			// URL最先应用，作为其他字段的基础
+			sort.SliceStable(list, func(i, j int) bool {
+				return list[i].field == "URL" && list[j].field != "URL"
+			})
+
+			var rc RedisConfig
+			for _, e := range list {
+				if err := envFields[e.field](&rc, e.val); err != nil {
+					errs = append(errs, fmt.Errorf("%w: env %s=%q: %v", ErrInvalidConfig, e.key, e.val, err))
+				}
+			}
+			gc.Instances[name] = rc
+		}
+		cfg.Groups[groupName] = gc
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return ManagerConfig{}, err
+	}
+	return cfg, nil
+}
+
+// splitEnvKey 将去掉前缀的变量名拆分为组名、实例名和字段名
+func splitEnvKey(key string) (groupName, name, field string, ok bool) {
+	for _, field := range envFieldNames {
+		rest, found := strings.CutSuffix(key, "_"+field)
+		if !found {
+			continue
+		}
+		groupName, name, found := strings.Cut(rest, "_")
+		if !found || groupName == "" || name == "" {
+			continue
+		}
+		return strings.ToLower(groupName), strings.ToLower(name), field, true
+	}
+	return "", "", "", false
+}
+
+// ManagerConfigFromEnv 从当前进程的环境变量解析管理器配置，prefix为空时使用DefaultEnvPrefix
+func ManagerConfigFromEnv(prefix string) (ManagerConfig, error) {
+	return ParseEnv(prefix, os.Environ())
+}
+
+// NewManagerFromEnv 根据环境变量创建多组管理器并注册所有实例，opts传给NewManager
+func NewManagerFromEnv(prefix string, opts ...Option) (Manager, error) {
+	cfg, err := ManagerConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerFromConfig(cfg, opts...)
+}
+
+// RegisterEnv 根据环境变量向已有的管理器注册实例
+func RegisterEnv(ctx context.Context, manager Manager, prefix string) error {
+	cfg, err := ManagerConfigFromEnv(prefix)
+	if err != nil {
+		return err
+	}
+	return RegisterManagerConfig(ctx, manager, cfg)
+}
+
+func envString(field func(cfg *RedisConfig) *string) envSetter {
+	return func(cfg *RedisConfig, val string) error {
+		*field(cfg) = val
+		return nil
+	}
+}
+
+func envStrings(field func(cfg *RedisConfig) *[]string) envSetter {
+	return func(cfg *RedisConfig, val string) error {
+		*field(cfg) = splitList(val)
+		return nil
+	}
+}
+
+func envInt(field func(cfg *RedisConfig) *int) envSetter {
+	return func(cfg *RedisConfig, val string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			return err
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func envBool(field func(cfg *RedisConfig) *bool) envSetter {
+	return func(cfg *RedisConfig, val string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(val))
+		if err != nil {
+			return err
+		}
+		*field(cfg) = b
+		return nil
+	}
+}
+
+func envDuration(field func(cfg *RedisConfig) *time.Duration) envSetter {
+	return func(cfg *RedisConfig, val string) error {
+		d, err := parseURLDuration(strings.TrimSpace(val))
+		if err != nil {
+			return err
+		}
+		*field(cfg) = d
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package mgredis
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnv 测试从环境变量解析配置
+func TestParseEnv(t *testing.T) {
+	t.Run("解析所有字段", func(t *testing.T) {
+		cfg, err := ParseEnv("", []string{
+			"PATH=/usr/bin",
+			"MGREDIS_CACHE_PRIMARY_ADDR=127.0.0.1:6379",
+			"MGREDIS_CACHE_PRIMARY_NAME=主缓存",
+			"MGREDIS_CACHE_PRIMARY_POOL_SIZE=20",
+			"MGREDIS_CACHE_PRIMARY_READ_TIMEOUT=1s",
+			"MGREDIS_CACHE_PRIMARY_DIAL_TIMEOUT=2",
+			"MGREDIS_CACHE_PRIMARY_TLS_ENABLED=true",
+			"MGREDIS_CACHE_PRIMARY_TLS_SERVER_NAME=cache.local",
+			"MGREDIS_SESSION_HA_MASTER_NAME=mymaster",
+			"MGREDIS_SESSION_HA_SENTINEL_ADDRS=s1:26379, s2:26379",
+			"MGREDIS_SESSION_HA_REPLICA_ONLY=1",
+		})
+		if err != nil {
+			t.Fatalf("ParseEnv失败: %v", err)
+		}
+
+		primary := cfg.Groups["cache"].Instances["primary"]
+		want := RedisConfig{
+			Name:        "主缓存",
+			Addr:        "127.0.0.1:6379",
+			PoolSize:    20,
+			ReadTimeout: time.Second,
+			DialTimeout: 2 * time.Second,
+			TLS:         TLSConfig{Enabled: true, ServerName: "cache.local"},
+		}
+		if !reflect.DeepEqual(primary, want) {
+			t.Errorf("预期%+v，实际为%+v", want, primary)
+		}
+
+		ha := cfg.Groups["session"].Instances["ha"]
+		if ha.MasterName != "mymaster" || !ha.ReplicaOnly || !reflect.DeepEqual(ha.SentinelAddrs, []string{"s1:26379", "s2:26379"}) {
+			t.Errorf("Sentinel配置解析错误: %+v", ha)
+		}
+	})
+
+	t.Run("字段名后缀歧义", func(t *testing.T) {
+		cfg, err := ParseEnv("APP", []string{
+			"APP_CACHE_MASTER_NAME=主节点",
+			"APP_CACHE_MASTER_ADDR=127.0.0.1:6379",
+		})
+		if err != nil {
+			t.Fatalf("ParseEnv失败: %v", err)
+		}
+		master := cfg.Groups["cache"].Instances["master"]
+		if master.Name != "主节点" || master.MasterName != "" {
+			t.Errorf("预期解析为实例master的NAME字段: %+v", master)
+		}
+	})
+
+	t.Run("URL作为基础配置", func(t *testing.T) {
+		cfg, err := ParseEnv("", []string{
+			"MGREDIS_CACHE_MAIN_POOL_SIZE=30",
+			"MGREDIS_CACHE_MAIN_URL=redis://:secret@10.0.0.1:6380/3?pool_size=5",
+		})
+		if err != nil {
+			t.Fatalf("ParseEnv失败: %v", err)
+		}
+		main := cfg.Groups["cache"].Instances["main"]
+		if main.Addr != "10.0.0.1:6380" || main.DB != 3 || main.Password != "secret" || main.PoolSize != 30 {
+			t.Errorf("URL与字段合并错误: %+v", main)
+		}
+	})
+
+	t.Run("一次报告所有无法解析的值", func(t *testing.T) {
+		_, err := ParseEnv("", []string{
+			"MGREDIS_CACHE_MAIN_POOL_SIZE=many",
+			"MGREDIS_CACHE_MAIN_READ_TIMEOUT=soon",
+			"MGREDIS_CACHE_MAIN_REPLICA_ONLY=maybe",
+			"MGREDIS_CACHE_UNKNOWN=1",
+		})
+		if !IsErrInvalidConfig(err) {
+			t.Fatalf("预期ErrInvalidConfig错误，实际得到: %v", err)
+		}
+		for _, key := range []string{"POOL_SIZE", "READ_TIMEOUT", "REPLICA_ONLY"} {
+			if !strings.Contains(err.Error(), key) {
+				t.Errorf("错误信息缺少%s: %v", key, err)
+			}
+		}
+		if strings.Contains(err.Error(), "UNKNOWN") {
+			t.Errorf("无法识别的变量不应报告为错误: %v", err)
+		}
+	})
+
+	t.Run("忽略无法识别的字段", func(t *testing.T) {
+		cfg, err := ParseEnv("", []string{
+			"MGREDIS_CACHE_MAIN_ADDR=127.0.0.1:6379",
+			"MGREDIS_CACHE_MAIN_POOLSIZE=10",
+			"MGREDIS_VERSION=1.2.0",
+			"MGREDIS_STATS_MAIN_UNKNOWN=1",
+		})
+		if err != nil {
+			t.Fatalf("无法识别的字段应被忽略，实际得到: %v", err)
+		}
+		if len(cfg.Groups) != 1 || len(cfg.Groups["cache"].Instances) != 1 {
+			t.Errorf("无法识别的字段不应创建组或实例: %+v", cfg.Groups)
+		}
+		if main := cfg.Groups["cache"].Instances["main"]; main.Addr != "127.0.0.1:6379" || main.PoolSize != 0 {
+			t.Errorf("实例配置错误: %+v", main)
+		}
+	})
+}
+
+// TestNewManagerFromEnv 测试根据环境变量创建管理器
+func TestNewManagerFromEnv(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t, nil)
+
+	t.Setenv("MGREDIS_CACHE_PRIMARY_ADDR", srv.Addr())
+	t.Setenv("MGREDIS_CACHE_PRIMARY_POOL_SIZE", "4")
+	t.Setenv("MGREDIS_LIMITER_PRIMARY_ADDR", srv.Addr())
+
+	manager, err := NewManagerFromEnv("")
+	if err != nil {
+		t.Fatalf("NewManagerFromEnv失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	client, err := manager.MustGroup("cache").Get(ctx, "primary")
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	if client.Options().PoolSize != 4 {
+		t.Errorf("预期PoolSize为4，实际为%d", client.Options().PoolSize)
+	}
+
+	t.Run("注册到已有管理器", func(t *testing.T) {
+		existing := NewManager()
+		defer existing.Close(ctx)
+
+		if err := RegisterEnv(ctx, existing, ""); err != nil {
+			t.Fatalf("RegisterEnv失败: %v", err)
+		}
+		if _, err := existing.Group("limiter"); err != nil {
+			t.Errorf("预期注册limiter组: %v", err)
+		}
+	})
+
+	t.Run("缺少地址", func(t *testing.T) {
+		t.Setenv("MGREDIS_BROKEN_MAIN_DB", "1")
+		if _, err := NewManagerFromEnv(""); !IsErrNoAddr(err) {
+			t.Errorf("预期ErrNoAddr错误，实际得到: %v", err)
+		}
+	})
+}
@@ -1,16 +1,40 @@
 package mgredis
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	// ErrNoAddr 缺少Redis服务器地址
 	ErrNoAddr = errors.New("mgredis: missing Addr in RedisConfig")
 
+	// ErrNoSentinelAddrs Sentinel模式下缺少Sentinel节点地址
+	ErrNoSentinelAddrs = errors.New("mgredis: missing SentinelAddrs in RedisConfig")
+
+	// ErrNoClusterAddrs 缺少Redis Cluster种子节点地址
+	ErrNoClusterAddrs = errors.New("mgredis: missing Addrs in ClusterConfig")
+
+	// ErrInvalidURL Redis连接URL格式错误
+	ErrInvalidURL = errors.New("mgredis: invalid redis url")
+
+	// ErrInvalidTLSConfig TLS配置无效
+	ErrInvalidTLSConfig = errors.New("mgredis: invalid tls config")
+
+	// ErrInvalidConfig 配置文件或配置项无效
+	ErrInvalidConfig = errors.New("mgredis: invalid config")
+
 	// ErrPingFailed Redis连接测试失败
 	ErrPingFailed = errors.New("mgredis: ping failed")
 
+	// ErrAuthFailed Redis认证失败，用户名或密码错误
+	ErrAuthFailed = errors.New("mgredis: auth failed")
+
 	// ErrClientNotFound 未找到指定名称的Redis客户端
 	ErrClientNotFound = errors.New("mgredis: redis client not found")
+
+	// ErrCircuitOpen 熔断器已打开，命令未发送到Redis
+	ErrCircuitOpen = errors.New("mgredis: circuit breaker is open")
 )
 
 // IsErrNoAddr 判断是否为缺少地址错误
@@ -18,12 +42,134 @@ func IsErrNoAddr(err error) bool {
 	return errors.Is(err, ErrNoAddr)
 }
 
+// IsErrNoSentinelAddrs 判断是否为缺少Sentinel节点地址错误
+func IsErrNoSentinelAddrs(err error) bool {
+	return errors.Is(err, ErrNoSentinelAddrs)
+}
+
+// IsErrNoClusterAddrs 判断是否为缺少集群节点地址错误
+func IsErrNoClusterAddrs(err error) bool {
+	return errors.Is(err, ErrNoClusterAddrs)
+}
+
+// IsErrInvalidURL 判断是否为连接URL格式错误
+func IsErrInvalidURL(err error) bool {
+	return errors.Is(err, ErrInvalidURL)
+}
+
+// IsErrInvalidTLSConfig 判断是否为TLS配置无效错误
+func IsErrInvalidTLSConfig(err error) bool {
+	return errors.Is(err, ErrInvalidTLSConfig)
+}
+
+// IsErrInvalidConfig 判断是否为配置无效错误
+func IsErrInvalidConfig(err error) bool {
+	return errors.Is(err, ErrInvalidConfig)
+}
+
 // IsErrPingFailed 判断是否为连接测试失败错误
 func IsErrPingFailed(err error) bool {
 	return errors.Is(err, ErrPingFailed)
 }
 
+// IsErrAuthFailed 判断是否为认证失败错误
+func IsErrAuthFailed(err error) bool {
+	return errors.Is(err, ErrAuthFailed)
+}
+
 // IsErrClientNotFound 判断是否为客户端未找到错误
 func IsErrClientNotFound(err error) bool {
 	return errors.Is(err, ErrClientNotFound)
 }
+
+// IsErrCircuitOpen 判断是否为熔断错误
+func IsErrCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// Op 出错的操作
+type Op string
+
+const (
+	// OpOpen 创建客户端（检查配置、获取凭据、创建TLS配置等）
+	OpOpen Op = "open"
+	// OpPing 创建客户端后的连接测试
+	OpPing Op = "ping"
+	// OpClose 关闭客户端
+	OpClose Op = "close"
+	// OpGet 从组中获取客户端
+	OpGet Op = "get"
+	// OpUnregister 注销客户端
+	OpUnregister Op = "unregister"
+	// OpReconfigure 替换客户端配置
+	OpReconfigure Op = "reconfigure"
+	// OpCommand 执行命令
+	OpCommand Op = "command"
+)
+
+// Error 携带组名、实例名、地址和操作的错误
+//
+// Kind为包内的哨兵错误（如ErrPingFailed），Err为底层原因，
+// errors.Is可同时匹配二者，errors.As可取出底层错误（如*net.OpError）。
+type Error struct {
+	// Op 出错的操作
+	Op Op
+	// Group 组名，单组管理器为"defaultGroup"
+	Group string
+	// Name 实例名
+	Name string
+	// Addr 实例地址，Sentinel模式下为主节点名
+	Addr string
+	// Kind 错误类别，可能为nil
+	Kind error
+	// Err 底层原因
+	Err error
+}
+
+// Error 返回错误信息，如 "mgredis: ping cache/main (127.0.0.1:6379): ping failed: dial tcp ..."
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("mgredis: ")
+	b.WriteString(string(e.Op))
+	if e.Group != "" || e.Name != "" {
+		b.WriteString(" " + e.Group + "/" + e.Name)
+	}
+	if e.Addr != "" {
+		b.WriteString(" (" + e.Addr + ")")
+	}
+	for _, err := range []error{e.Kind, e.Err} {
+		if err != nil {
+			b.WriteString(": " + strings.TrimPrefix(err.Error(), "mgredis: "))
+		}
+	}
+	return b.String()
+}
+
+// Unwrap 返回错误类别和底层原因
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	for _, err := range []error{e.Kind, e.Err} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// instanceError 为错误补充组名和实例名，非*Error的错误包装为op操作的*Error
+func instanceError(op Op, key InstanceKey, addr string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Group == "" && e.Name == "" {
+			e.Group, e.Name = key.Group, key.Name
+		}
+		if e.Addr == "" {
+			e.Addr = addr
+		}
+		return err
+	}
+	return &Error{Op: op, Group: key.Group, Name: key.Name, Addr: addr, Err: err}
+}
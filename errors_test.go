@@ -0,0 +1,91 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/qq1060656096/bizutil/registry"
+)
+
+// TestError 测试*Error携带的上下文及与哨兵错误的匹配
+func TestError(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Ping失败", func(t *testing.T) {
+		m := NewManager()
+		defer m.Close(ctx)
+		m.AddGroup("cache")
+		g := m.MustGroup("cache")
+		_, _ = g.Register(ctx, "main", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: 1})
+
+		_, err := g.Get(ctx, "main")
+		if !IsErrPingFailed(err) {
+			t.Fatalf("预期ErrPingFailed，实际为: %v", err)
+		}
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("预期*Error，实际为%T", err)
+		}
+		if e.Op != OpPing || e.Group != "cache" || e.Name != "main" || e.Addr != "127.0.0.1:1" {
+			t.Errorf("上下文错误: %+v", e)
+		}
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) {
+			t.Errorf("应能取出底层*net.OpError: %v", err)
+		}
+		want := "mgredis: ping cache/main (127.0.0.1:1): ping failed: "
+		if got := err.Error(); len(got) < len(want) || got[:len(want)] != want {
+			t.Errorf("错误信息应以%q开头，实际为%q", want, got)
+		}
+	})
+
+	t.Run("配置无效", func(t *testing.T) {
+		group := New()
+		defer group.Close(ctx)
+		_, _ = group.Register(ctx, "empty", RedisConfig{})
+
+		_, err := group.Get(ctx, "empty")
+		var e *Error
+		if !errors.As(err, &e) || e.Op != OpOpen || e.Name != "empty" || !IsErrNoAddr(err) {
+			t.Errorf("预期open操作的ErrNoAddr，实际为: %v", err)
+		}
+		if err.Error() != "mgredis: open defaultGroup/empty: missing Addr in RedisConfig" {
+			t.Errorf("错误信息不符: %q", err.Error())
+		}
+	})
+
+	t.Run("客户端不存在", func(t *testing.T) {
+		group := New()
+		defer group.Close(ctx)
+
+		_, err := group.Get(ctx, "missing")
+		if !IsErrClientNotFound(err) || !errors.Is(err, registry.ErrResourceNotFound) {
+			t.Errorf("应同时匹配ErrClientNotFound和registry.ErrResourceNotFound: %v", err)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Op != OpGet || e.Group != "defaultGroup" || e.Name != "missing" {
+			t.Errorf("上下文错误: %v", err)
+		}
+		if err := group.Unregister(ctx, "missing"); !IsErrClientNotFound(err) {
+			t.Errorf("注销不存在的客户端应返回ErrClientNotFound: %v", err)
+		}
+	})
+
+	t.Run("关闭失败", func(t *testing.T) {
+		group := New()
+		_, _ = group.Register(ctx, "main", RedisConfig{Addr: newFakeRedisNamed(t, "a").Addr()})
+		client := group.MustGet(ctx, "main")
+		_ = client.Close()
+
+		errs := group.Close(ctx)
+		if len(errs) != 1 {
+			t.Fatalf("预期1个错误，实际为%v", errs)
+		}
+		var e *Error
+		if !errors.As(errs[0], &e) || e.Op != OpClose || e.Name != "main" || e.Addr == "" {
+			t.Errorf("上下文错误: %v", errs[0])
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultFailoverFailAfter 连续失败多少次后切换到下一个地址
+	DefaultFailoverFailAfter = 3
+
+	// DefaultFailoverCheckInterval 检查当前地址和主地址的默认间隔
+	DefaultFailoverCheckInterval = time.Second
+)
+
+// FailoverConfig 故障切换策略，仅在设置了RedisConfig.FailoverAddrs时生效
+type FailoverConfig struct {
+	// FailAfter 当前地址连续检查失败多少次后切换到下一个可用地址，默认为3
+	FailAfter int `json:"fail_after"`
+
+	// CheckInterval 检查当前地址的间隔，默认为1秒，每次检查的超时也为该值
+	CheckInterval time.Duration `json:"check_interval"`
+
+	// Failback 为true时，使用备用地址期间主地址（Addr）恢复后切回主地址
+	Failback bool `json:"failback"`
+}
+
+// CheckAndSetDefaults 设置默认值
+func (f *FailoverConfig) CheckAndSetDefaults() error {
+	if f.FailAfter <= 0 {
+		f.FailAfter = DefaultFailoverFailAfter
+	}
+	if f.CheckInterval <= 0 {
+		f.CheckInterval = DefaultFailoverCheckInterval
+	}
+	return nil
+}
+
+// UnmarshalJSON 解析JSON配置，check_interval同时支持"3s"格式的字符串和表示秒数的整数
+func (f *FailoverConfig) UnmarshalJSON(data []byte) error {
+	type plain FailoverConfig
+	aux := struct {
+		*plain
+		CheckInterval *jsonDuration `json:"check_interval"`
+	}{
+		plain:         (*plain)(f),
+		CheckInterval: (*jsonDuration)(&f.CheckInterval),
+	}
+	return json.Unmarshal(data, &aux)
+}
+
+// FailoverEvent 故障切换事件
+type FailoverEvent struct {
+	// Group 组名
+	Group string
+	// Name 实例名
+	Name string
+	// From 切换前的地址
+	From string
+	// To 切换后的地址
+	To string
+	// Failback 是否为主地址恢复后的切回
+	Failback bool
+	// Err 触发切换的最近一次检查错误，切回时为nil
+	Err error
+	// Time 切换时间
+	Time time.Time
+}
+
+// FailoverHandler 故障切换回调
+type FailoverHandler func(FailoverEvent)
+
+// WithFailoverHandler 添加故障切换回调，可多次调用添加多个回调
+// 回调在故障切换的goroutine中同步执行，不应长时间阻塞
+func WithFailoverHandler(fn FailoverHandler) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.failoverHandlers = append(o.failoverHandlers, fn)
+		}
+	}
+}
+
+// failoverAddrs 返回按优先级排列的候选地址，第一个为主地址
+func (cfg *RedisConfig) failoverAddrs() []string {
+	return append([]string{cfg.Addr}, cfg.FailoverAddrs...)
+}
+
+// withAddr 返回使用指定地址的配置副本
+func (cfg RedisConfig) withAddr(addr string) RedisConfig {
+	cfg.Addr = addr
+	cfg.FailoverAddrs = nil
+	cfg.Replicas = nil
+	return cfg
+}
+
+// openFailover 按优先级依次尝试候选地址，返回第一个可用地址的客户端并开始监控
+func (g *group) openFailover(ctx context.Context, name string, cfg RedisConfig) (*redis.Client, error) {
+	var errs []error
+	for i, addr := range cfg.failoverAddrs() {
+		client, err := g.openAddr(ctx, name, cfg.withAddr(addr))
+		if err == nil {
+			go g.monitorFailover(name, cfg, i, client)
+			return client, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// monitorFailover 定期检查当前地址，连续失败时切换到下一个可用地址，启用Failback时在主地址恢复后切回
+//
+// 客户端被关闭（注销、替换或组关闭）或不再是组中的当前客户端时退出。
+func (g *group) monitorFailover(name string, cfg RedisConfig, active int, client *redis.Client) {
+	policy := cfg.Failover
+	_ = policy.CheckAndSetDefaults()
+	addrs := cfg.failoverAddrs()
+
+	ticker := time.NewTicker(policy.CheckInterval)
+	defer ticker.Stop()
+
+	// 等待切回期间使用不安装钩子的探测客户端检查主地址，探测成功后才创建正式客户端
+	var probe *redis.Client
+	defer func() {
+		if probe != nil {
+			_ = probe.Close()
+		}
+	}()
+
+	failures := 0
+	for range ticker.C {
+		if v, ok := g.state.opened.Load(name); ok && v != client {
+			return
+		}
+		// 每次检查不超过检查间隔，卡住的连接不会阻塞故障检测
+		ctx, cancel := context.WithTimeout(context.Background(), policy.CheckInterval)
+		err := client.Ping(ctx).Err()
+		cancel()
+		if errors.Is(err, redis.ErrClosed) {
+			return
+		}
+
+		if err == nil {
+			failures = 0
+			if active > 0 && policy.Failback &&
+				g.probeAddr(name, cfg.withAddr(addrs[0]), &probe, policy.CheckInterval) &&
+				g.failover(name, cfg, client, active, []int{0}, nil) {
+				return
+			}
+			continue
+		}
+
+		failures++
+		if failures < policy.FailAfter {
+			continue
+		}
+		failures = 0
+		// 从当前地址的下一个开始依次尝试其他地址
+		order := make([]int, 0, len(addrs)-1)
+		for i := 1; i < len(addrs); i++ {
+			order = append(order, (active+i)%len(addrs))
+		}
+		if g.failover(name, cfg, client, active, order, err) {
+			return
+		}
+	}
+}
+
+// probeAddr 使用探测客户端Ping指定地址，探测客户端在多次检查间复用
+// 探测客户端不安装指标、追踪等钩子，失败只记录Debug日志
+func (g *group) probeAddr(name string, cfg RedisConfig, probe **redis.Client, timeout time.Duration) bool {
+	if *probe == nil {
+		client, err := newProbeClient(cfg)
+		if err != nil {
+			g.opts.log(slog.LevelDebug, "redis failback probe failed", g.key(name), cfg, "error", err)
+			return false
+		}
+		*probe = client
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := (*probe).Ping(ctx).Err(); err != nil {
+		g.opts.log(slog.LevelDebug, "redis failback probe failed", g.key(name), cfg, "error", err)
+		return false
+	}
+	return true
+}
+
+// newProbeClient 创建只保持一个连接、不重试的客户端，用于探测地址是否可用
+func newProbeClient(cfg RedisConfig) (*redis.Client, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, err
+	}
+	cfg.PoolSize, cfg.MinIdleConns, cfg.MaxRetries = 1, 0, -1
+	tlsConfig, err := cfg.TLS.Build(cfg.tlsServerName())
+	if err != nil {
+		return nil, err
+	}
+	return newClient(cfg, tlsConfig), nil
+}
+
+// failover 按order依次尝试候选地址，成功时替换组中的客户端并为新客户端开始监控
+// 返回true表示当前监控应退出（已切换，或客户端已被注销、替换）
+func (g *group) failover(name string, cfg RedisConfig, old *redis.Client, from int, order []int, reason error) bool {
+	addrs := cfg.failoverAddrs()
+	for _, i := range order {
+		client, err := g.openAddr(context.Background(), name, cfg.withAddr(addrs[i]))
+		if err != nil {
+			continue
+		}
+
+		var detached []*redis.Client
+		replaced, err := g.replace(name, cfg, old, client, &detached)
+		if !replaced || err != nil {
+			g.discard(client)
+			return true
+		}
+
+		for _, c := range detached {
+			g.drain(name, cfg.withAddr(addrs[from]), c)
+		}
+		go g.monitorFailover(name, cfg, i, client)
+
+		event := FailoverEvent{
+			Group:    g.name,
+			Name:     name,
+			From:     addrs[from],
+			To:       addrs[i],
+			Failback: reason == nil,
+			Err:      reason,
+			Time:     time.Now(),
+		}
+		g.opts.log(slog.LevelWarn, "redis client failed over", g.key(name), cfg.withAddr(addrs[i]),
+			"from", event.From, "failback", event.Failback)
+		for _, fn := range g.opts.failoverHandlers {
+			fn(event)
+		}
+		return true
+	}
+	return false
+}
+
+// replace 在old仍是组中的当前客户端且配置未变化时，将其替换为client
+func (g *group) replace(name string, cfg RedisConfig, old, client *redis.Client, detached *[]*redis.Client) (bool, error) {
+	ctx := context.Background()
+	g.state.mu.Lock()
+	defer g.state.mu.Unlock()
+
+	if v, ok := g.state.opened.Load(name); !ok || v != old {
+		return false, nil
+	}
+	current, err := g.Group.Config(ctx, name)
+	if err != nil || !configEqual(current, cfg) {
+		return false, nil
+	}
+	return true, g.swap(ctx, name, cfg, client, detached)
+}
@@ -0,0 +1,261 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newSwitchableRedis 启动一个对GET返回value的fakeRedis，down为true时PING返回错误
+func newSwitchableRedis(t *testing.T, value string, down *atomic.Bool) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		switch {
+		case strings.EqualFold(args[0], "GET"):
+			return value, true
+		case strings.EqualFold(args[0], "PING") && down.Load():
+			return respError("ERR injected failure"), true
+		}
+		return nil, false
+	})
+}
+
+// waitFailover 等待故障切换事件
+func waitFailover(t *testing.T, events <-chan FailoverEvent) FailoverEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待故障切换超时")
+		return FailoverEvent{}
+	}
+}
+
+// TestFailover 测试主地址故障时切换到备用地址，并在恢复后切回
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+
+	var primaryDown, backupDown atomic.Bool
+	primary := newSwitchableRedis(t, "primary", &primaryDown)
+	backup := newSwitchableRedis(t, "backup", &backupDown)
+
+	events := make(chan FailoverEvent, 4)
+	group := New(WithGracePeriod(0), WithFailoverHandler(func(e FailoverEvent) {
+		events <- e
+	}))
+	defer group.Close(ctx)
+
+	cfg := RedisConfig{
+		Addr:          primary.Addr(),
+		FailoverAddrs: []string{backup.Addr()},
+		Failover: FailoverConfig{
+			FailAfter:     2,
+			CheckInterval: 10 * time.Millisecond,
+			Failback:      true,
+		},
+	}
+	_, _ = group.Register(ctx, "cache", cfg)
+	old := group.MustGet(ctx, "cache")
+	if got := old.Get(ctx, "k").Val(); got != "primary" {
+		t.Fatalf("预期连接主地址，实际为%q", got)
+	}
+
+	// 主地址故障，切换到备用地址
+	primaryDown.Store(true)
+	e := waitFailover(t, events)
+	if e.Group != "defaultGroup" || e.Name != "cache" || e.From != primary.Addr() || e.To != backup.Addr() ||
+		e.Failback || e.Err == nil {
+		t.Errorf("切换事件不符: %+v", e)
+	}
+	if got := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); got != "backup" {
+		t.Errorf("切换后应连接备用地址，实际为%q", got)
+	}
+	if err := old.Ping(ctx).Err(); !errors.Is(err, redis.ErrClosed) {
+		t.Errorf("旧客户端应被关闭，实际为: %v", err)
+	}
+	if got := group.MustConfig(ctx, "cache"); !configEqual(got, cfg) {
+		t.Errorf("切换不应修改注册的配置: %+v", got)
+	}
+	if st := group.Status(); len(st) != 1 || st[0].Addr != backup.Addr() {
+		t.Errorf("Status应报告当前地址: %+v", st)
+	}
+
+	// 主地址恢复，切回
+	primaryDown.Store(false)
+	e = waitFailover(t, events)
+	if e.From != backup.Addr() || e.To != primary.Addr() || !e.Failback || e.Err != nil {
+		t.Errorf("切回事件不符: %+v", e)
+	}
+	if got := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); got != "primary" {
+		t.Errorf("切回后应连接主地址，实际为%q", got)
+	}
+}
+
+// TestFailoverOpen 测试创建客户端时使用第一个可用地址
+func TestFailoverOpen(t *testing.T) {
+	ctx := context.Background()
+
+	var primaryDown, backupDown atomic.Bool
+	primaryDown.Store(true)
+	primary := newSwitchableRedis(t, "primary", &primaryDown)
+	backup := newSwitchableRedis(t, "backup", &backupDown)
+
+	group := New()
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:          primary.Addr(),
+		FailoverAddrs: []string{backup.Addr()},
+	})
+	if got := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); got != "backup" {
+		t.Errorf("主地址不可用时应连接备用地址，实际为%q", got)
+	}
+
+	// 所有地址都不可用时返回每个地址的错误
+	backupDown.Store(true)
+	_, _ = group.Register(ctx, "down", RedisConfig{
+		Addr:          primary.Addr(),
+		FailoverAddrs: []string{backup.Addr()},
+	})
+	_, err := group.Get(ctx, "down")
+	if !IsErrPingFailed(err) || !strings.Contains(err.Error(), primary.Addr()) || !strings.Contains(err.Error(), backup.Addr()) {
+		t.Errorf("错误应包含所有地址: %v", err)
+	}
+
+	sentinel := RedisConfig{MasterName: "mymaster", SentinelAddrs: []string{"127.0.0.1:26379"}, FailoverAddrs: []string{"x"}}
+	if err := sentinel.CheckAndSetDefaults(); !IsErrInvalidConfig(err) {
+		t.Errorf("Sentinel模式不支持FailoverAddrs: %v", err)
+	}
+}
+
+// TestFailoverFailbackProbe 测试等待切回期间只通过探测客户端检查主地址
+func TestFailoverFailbackProbe(t *testing.T) {
+	ctx := context.Background()
+
+	var primaryDown, backupDown atomic.Bool
+	primaryDown.Store(true)
+	primary := newSwitchableRedis(t, "primary", &primaryDown)
+	backup := newSwitchableRedis(t, "backup", &backupDown)
+
+	var buf syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	events := make(chan FailoverEvent, 4)
+	group := New(WithLogger(logger), WithGracePeriod(0), WithFailoverHandler(func(e FailoverEvent) {
+		events <- e
+	}))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:          primary.Addr(),
+		FailoverAddrs: []string{backup.Addr()},
+		Failover:      FailoverConfig{CheckInterval: 10 * time.Millisecond, Failback: true},
+	})
+	if got := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); got != "backup" {
+		t.Fatalf("主地址不可用时应连接备用地址，实际为%q", got)
+	}
+
+	pingFailed := len(buf.records(t, "redis ping failed"))
+	opened := len(buf.records(t, "redis client opened"))
+	connects := countCommand(primary, "HELLO")
+	time.Sleep(150 * time.Millisecond)
+
+	if n := len(buf.records(t, "redis ping failed")); n != pingFailed {
+		t.Errorf("探测失败不应记录ERROR日志，新增%d条", n-pingFailed)
+	}
+	if n := len(buf.records(t, "redis client opened")); n != opened {
+		t.Errorf("探测成功前不应创建正式客户端，新增%d个", n-opened)
+	}
+	if len(buf.records(t, "redis failback probe failed")) == 0 {
+		t.Error("预期记录探测失败的Debug日志")
+	}
+	if n := countCommand(primary, "HELLO") - connects; n > 1 {
+		t.Errorf("探测客户端应复用连接，实际新建%d个连接", n)
+	}
+
+	// 主地址恢复后切回
+	primaryDown.Store(false)
+	e := waitFailover(t, events)
+	if !e.Failback || e.To != primary.Addr() {
+		t.Errorf("切回事件不符: %+v", e)
+	}
+	if got := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); got != "primary" {
+		t.Errorf("切回后应连接主地址，实际为%q", got)
+	}
+}
+
+// TestFailoverHangingPrimary 测试主地址不回复时检查在检查间隔内超时并切换
+func TestFailoverHangingPrimary(t *testing.T) {
+	ctx := context.Background()
+
+	var hang, backupDown atomic.Bool
+	primary := newHangingRedis(t, &hang)
+	backup := newSwitchableRedis(t, "backup", &backupDown)
+
+	events := make(chan FailoverEvent, 4)
+	group := New(WithGracePeriod(0), WithFailoverHandler(func(e FailoverEvent) {
+		events <- e
+	}))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:          primary.Addr(),
+		FailoverAddrs: []string{backup.Addr()},
+		ReadTimeout:   10 * time.Second,
+		Failover:      FailoverConfig{FailAfter: 2, CheckInterval: 20 * time.Millisecond},
+	})
+	group.MustGet(ctx, "cache")
+
+	hang.Store(true)
+	if e := waitFailover(t, events); e.To != backup.Addr() || e.Err == nil {
+		t.Errorf("切换事件不符: %+v", e)
+	}
+}
+
+// TestFailoverReplaceFailedReleasesBreaker 测试替换失败时关闭新客户端并移除其熔断器
+func TestFailoverReplaceFailedReleasesBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	var down atomic.Bool
+	primary := newSwitchableRedis(t, "primary", &down)
+	backup := newSwitchableRedis(t, "backup", &down)
+
+	gr := New(WithGracePeriod(0))
+	defer gr.Close(ctx)
+	cfg := RedisConfig{
+		Addr:           primary.Addr(),
+		FailoverAddrs:  []string{backup.Addr()},
+		Failover:       FailoverConfig{CheckInterval: time.Hour},
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 5},
+	}
+	_, _ = gr.Register(ctx, "cache", cfg)
+	gr.MustGet(ctx, "cache")
+
+	g := gr.(*group)
+	countBreakers := func() int {
+		n := 0
+		g.state.breakers.Range(func(_, _ interface{}) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	if n := countBreakers(); n != 1 {
+		t.Fatalf("预期1个熔断器，实际为%d", n)
+	}
+
+	// 传入的旧客户端不是组中的当前客户端，替换失败
+	stale := redis.NewClient(&redis.Options{Addr: primary.Addr()})
+	defer stale.Close()
+	if !g.failover("cache", cfg, stale, 0, []int{1}, errors.New("injected")) {
+		t.Fatal("客户端已被替换时监控应退出")
+	}
+	if n := countBreakers(); n != 1 {
+		t.Errorf("替换失败后不应残留新客户端的熔断器，实际有%d个", n)
+	}
+}
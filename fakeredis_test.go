@@ -0,0 +1,250 @@
+package mgredis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// respSimple RESP简单字符串回复，如 +OK
+type respSimple string
+
+// respError RESP错误回复，如 -ERR xxx
+type respError string
+
+// fakeHandler 处理一条命令并返回回复，返回 (nil, false) 表示使用默认处理
+type fakeHandler func(args []string) (reply interface{}, handled bool)
+
+// fakeRedis 测试用的最小RESP服务器，用于在没有真实Redis时验证连接流程
+type fakeRedis struct {
+	ln      net.Listener
+	handler fakeHandler
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	cmds  []string
+	wg    sync.WaitGroup
+}
+
+// newFakeRedis 启动一个监听本地随机端口的fakeRedis，测试结束时自动关闭
+func newFakeRedis(t testing.TB, handler fakeHandler) *fakeRedis {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动fakeRedis失败: %v", err)
+	}
+	return startFakeRedis(t, ln, handler)
+}
+
+// startFakeRedis 在指定监听器上启动fakeRedis
+func startFakeRedis(t testing.TB, ln net.Listener, handler fakeHandler) *fakeRedis {
+	t.Helper()
+
+	s := &fakeRedis{
+		ln:      ln,
+		handler: handler,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr 返回监听地址
+func (s *fakeRedis) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Host 返回监听主机
+func (s *fakeRedis) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr())
+	return host
+}
+
+// Port 返回监听端口
+func (s *fakeRedis) Port() string {
+	_, port, _ := net.SplitHostPort(s.Addr())
+	return port
+}
+
+// Commands 返回已收到的命令名（大写）
+func (s *fakeRedis) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.cmds...)
+}
+
+// DropConns 断开所有已建立的连接，模拟服务端重启或主动断开
+func (s *fakeRedis) DropConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+// Close 关闭服务器及所有连接
+func (s *fakeRedis) Close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *fakeRedis) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *fakeRedis) handle(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToUpper(args[0])
+		s.mu.Lock()
+		s.cmds = append(s.cmds, name)
+		s.mu.Unlock()
+
+		if name == "SUBSCRIBE" || name == "PSUBSCRIBE" {
+			for i, ch := range args[1:] {
+				writeReply(w, []interface{}{strings.ToLower(name), ch, int64(i + 1)})
+			}
+		} else {
+			writeReply(w, s.reply(name, args))
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) reply(name string, args []string) interface{} {
+	if s.handler != nil {
+		if reply, ok := s.handler(args); ok {
+			return reply
+		}
+	}
+
+	switch name {
+	case "HELLO":
+		// 不支持RESP3，让客户端回退到RESP2
+		return respError("ERR unknown command 'HELLO'")
+	case "PING":
+		return respSimple("PONG")
+	case "AUTH", "SELECT", "CLIENT", "READONLY", "QUIT":
+		return respSimple("OK")
+	}
+	return respError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+}
+
+// readCommand 读取一条RESP数组命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		// 内联命令
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// writeReply 将回复编码为RESP2格式
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		_, _ = w.WriteString("$-1\r\n")
+	case respSimple:
+		_, _ = fmt.Fprintf(w, "+%s\r\n", v)
+	case respError:
+		_, _ = fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		_, _ = fmt.Fprintf(w, ":%d\r\n", v)
+	case int:
+		_, _ = fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []string:
+		_, _ = fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	case []interface{}:
+		_, _ = fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		_, _ = fmt.Fprintf(w, "-ERR unsupported reply %T\r\n", v)
+	}
+}
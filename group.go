@@ -0,0 +1,597 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qq1060656096/bizutil/registry"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultGroupName 单组管理器的组名，与registry.New保持一致
+const defaultGroupName = "defaultGroup"
+
+// DefaultGracePeriod Reconfigure替换客户端后，旧客户端关闭前的默认等待时间
+const DefaultGracePeriod = 10 * time.Second
+
+// Option 管理器选项
+type Option func(*options)
+
+// options 管理器选项集合
+type options struct {
+	gracePeriod       time.Duration
+	warmUpConcurrency int
+	health            healthOptions
+	metrics           *Metrics
+	tracer            Tracer
+	logger            *slog.Logger
+	failoverHandlers  []FailoverHandler
+}
+
+// newOptions 应用选项并返回结果
+func newOptions(opts []Option) *options {
+	o := &options{
+		gracePeriod:       DefaultGracePeriod,
+		warmUpConcurrency: DefaultWarmUpConcurrency,
+		health: healthOptions{
+			timeout:         DefaultHealthCheckTimeout,
+			degradedLatency: DefaultHealthDegradedLatency,
+			downAfter:       DefaultHealthDownAfter,
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// instrument 为新创建的客户端安装钩子，启用熔断时返回熔断器
+// replica为读写分离客户端的从节点地址，主节点客户端为空
+func (o *options) instrument(key InstanceKey, replica string, cfg RedisConfig, client *redis.Client) *circuitBreaker {
+	if o.metrics != nil {
+		client.AddHook(o.metrics.hook(key, replica))
+	}
+	if o.tracer != nil {
+		client.AddHook(newTracingHook(o.tracer, key, cfg))
+	}
+	if o.logger != nil && cfg.SlowThreshold > 0 {
+		client.AddHook(&slowLogHook{opts: o, key: key, cfg: cfg, threshold: cfg.SlowThreshold})
+	}
+	// 熔断器最后安装，位于最内层，被拒绝的命令仍会被指标和追踪记录
+	breaker := newCircuitBreaker(o, key, cfg)
+	if breaker != nil {
+		client.AddHook(breaker)
+	}
+	return breaker
+}
+
+// WithGracePeriod 设置Reconfigure替换客户端后旧客户端的排空等待时间，
+// 等待期间仍持有旧客户端的调用方可以完成正在执行的命令
+func WithGracePeriod(d time.Duration) Option {
+	return func(o *options) {
+		if d >= 0 {
+			o.gracePeriod = d
+		}
+	}
+}
+
+// contextKey 组内部使用的上下文键
+type contextKey int
+
+const (
+	// preopenedKey 携带已创建的客户端，配置一致时opener直接返回该客户端
+	preopenedKey contextKey = iota
+	// detachedKey 携带旧客户端收集器，closer将客户端交给收集器而不是立即关闭
+	detachedKey
+	// openInfoKey 携带惰性创建客户端所属的组和实例名
+	openInfoKey
+)
+
+// openInfo 惰性创建客户端时由Get传给groupOpener的组信息
+type openInfo struct {
+	g    *group
+	name string
+}
+
+// preopened 在注册表锁外预先创建的客户端
+type preopened struct {
+	client *redis.Client
+	cfg    RedisConfig
+	// used 为true表示客户端已被放入组中
+	used bool
+}
+
+// groupState 同一组的所有Group实例共享的状态
+type groupState struct {
+	// mu 保证Reconfigure的注销和重新注册对其他操作是原子的
+	mu sync.RWMutex
+
+	// opened 已创建的客户端，名称 => *redis.Client，用于健康检查等不应触发创建的场景
+	opened sync.Map
+
+	// breakers 客户端的熔断器，*redis.Client => *circuitBreaker，仅包含启用熔断的客户端
+	breakers sync.Map
+
+	healthMu sync.Mutex
+	health   map[string]ClientStatus
+
+	// readWrite 读写分离客户端，名称 => *ReadWriteClient，主节点客户端被替换或移除时关闭
+	rwMu      sync.Mutex
+	readWrite map[string]*ReadWriteClient
+}
+
+// track 记录已创建的客户端
+func (s *groupState) track(name string, client *redis.Client) {
+	v, ok := s.opened.Load(name)
+	if ok && v != client {
+		s.breakers.Delete(v)
+		s.closeReadWrite(name)
+	}
+	if !ok || v != client {
+		s.opened.Store(name, client)
+	}
+}
+
+// circuit 返回客户端的熔断器状态，未启用熔断时为CircuitClosed
+func (s *groupState) circuit(client *redis.Client) CircuitState {
+	if b, ok := s.breakers.Load(client); ok {
+		return b.(*circuitBreaker).State()
+	}
+	return CircuitClosed
+}
+
+// untrack 移除已创建的客户端及其健康状态
+func (s *groupState) untrack(name string) {
+	if v, ok := s.opened.LoadAndDelete(name); ok {
+		s.breakers.Delete(v)
+	}
+	s.closeReadWrite(name)
+	s.healthMu.Lock()
+	delete(s.health, name)
+	s.healthMu.Unlock()
+}
+
+// reset 移除所有已创建的客户端及健康状态
+func (s *groupState) reset() {
+	s.opened.Range(func(key, _ interface{}) bool {
+		s.opened.Delete(key)
+		return true
+	})
+	s.breakers.Range(func(key, _ interface{}) bool {
+		s.breakers.Delete(key)
+		return true
+	})
+	s.healthMu.Lock()
+	s.health = nil
+	s.healthMu.Unlock()
+
+	// 组关闭时不再有调用方使用从节点客户端，立即关闭
+	s.rwMu.Lock()
+	for _, rw := range s.readWrite {
+		rw.closeNow()
+	}
+	s.readWrite = nil
+	s.rwMu.Unlock()
+}
+
+// closeReadWrite 关闭并移除读写分离客户端
+func (s *groupState) closeReadWrite(name string) {
+	s.rwMu.Lock()
+	defer s.rwMu.Unlock()
+	if rw, ok := s.readWrite[name]; ok {
+		rw.close()
+		delete(s.readWrite, name)
+	}
+}
+
+// group 是Group接口的实现，在registry.Group之上增加热更新等能力
+type group struct {
+	registry.Group[RedisConfig, *redis.Client]
+
+	name  string
+	opts  *options
+	state *groupState
+
+	// checker 单组管理器的健康检查，多组管理器的组由管理器统一检查
+	checker *healthChecker
+}
+
+// newGroup 包装registry.Group
+func newGroup(rg registry.Group[RedisConfig, *redis.Client], name string, opts *options, state *groupState) *group {
+	return &group{
+		Group: rg,
+		name:  name,
+		opts:  opts,
+		state: state,
+	}
+}
+
+// Get 根据名称获取客户端，Reconfigure进行中时等待替换完成
+func (g *group) Get(ctx context.Context, name string) (*redis.Client, error) {
+	g.state.mu.RLock()
+	defer g.state.mu.RUnlock()
+
+	// 已创建的客户端直接返回，注销和替换都持有写锁，因此与注册表一致
+	if client, ok := g.state.opened.Load(name); ok {
+		return client.(*redis.Client), nil
+	}
+	client, err := g.Group.Get(context.WithValue(ctx, openInfoKey, &openInfo{g: g, name: name}), name)
+	if err != nil {
+		return nil, notFoundError(OpGet, g.key(name), err)
+	}
+	g.state.track(name, client)
+	return client, nil
+}
+
+// MustGet 根据名称获取客户端，如果获取失败则触发panic
+func (g *group) MustGet(ctx context.Context, name string) *redis.Client {
+	client, err := g.Get(ctx, name)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Config 返回客户端的当前配置
+func (g *group) Config(ctx context.Context, name string) (RedisConfig, error) {
+	g.state.mu.RLock()
+	defer g.state.mu.RUnlock()
+	return g.Group.Config(ctx, name)
+}
+
+// MustConfig 返回客户端的当前配置，如果获取失败则触发panic
+func (g *group) MustConfig(ctx context.Context, name string) RedisConfig {
+	cfg, err := g.Config(ctx, name)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Register 注册客户端配置
+func (g *group) Register(ctx context.Context, name string, cfg RedisConfig) (bool, error) {
+	g.state.mu.RLock()
+	defer g.state.mu.RUnlock()
+	return g.Group.Register(ctx, name, cfg)
+}
+
+// Unregister 注销客户端，已创建的客户端会被关闭
+func (g *group) Unregister(ctx context.Context, name string) error {
+	g.state.mu.Lock()
+	defer g.state.mu.Unlock()
+
+	cfg, _ := g.Group.Config(ctx, name)
+	var old []*redis.Client
+	g.state.untrack(name)
+	if err := g.Group.Unregister(context.WithValue(ctx, detachedKey, &old), name); err != nil {
+		return notFoundError(OpUnregister, g.key(name), err)
+	}
+
+	g.opts.log(slog.LevelInfo, "redis client unregistered", g.key(name), cfg)
+	var errs []error
+	for _, c := range old {
+		if err := g.closeClient(name, cfg, c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭组内所有客户端
+func (g *group) Close(ctx context.Context) []error {
+	if g.checker != nil {
+		g.checker.stop()
+	}
+
+	g.state.mu.Lock()
+	defer g.state.mu.Unlock()
+
+	closing := make(map[*redis.Client]closingClient)
+	g.closing(ctx, closing)
+	var detached []*redis.Client
+	errs := g.Group.Close(context.WithValue(ctx, detachedKey, &detached))
+	g.state.reset()
+	return append(errs, closeDetached(detached, closing)...)
+}
+
+// closingClient 待关闭客户端所属的组、实例名和配置
+type closingClient struct {
+	g    *group
+	name string
+	cfg  RedisConfig
+}
+
+// closing 收集组内已创建的客户端，用于关闭时在错误和日志中标识实例
+func (g *group) closing(ctx context.Context, into map[*redis.Client]closingClient) {
+	g.state.opened.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		cfg, _ := g.Group.Config(ctx, name)
+		into[value.(*redis.Client)] = closingClient{g: g, name: name, cfg: cfg}
+		return true
+	})
+}
+
+// closeDetached 关闭从注册表中移除的客户端，返回所有关闭失败的*Error
+func closeDetached(detached []*redis.Client, closing map[*redis.Client]closingClient) []error {
+	var errs []error
+	for _, c := range detached {
+		info, ok := closing[c]
+		if !ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, &Error{Op: OpClose, Err: err})
+			}
+			continue
+		}
+		if err := info.g.closeClient(info.name, info.cfg, c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// closeClient 关闭客户端并记录日志，失败时返回*Error
+func (g *group) closeClient(name string, cfg RedisConfig, client *redis.Client) error {
+	if err := client.Close(); err != nil {
+		g.opts.log(slog.LevelWarn, "redis client close failed", g.key(name), cfg, "error", err)
+		return &Error{Op: OpClose, Group: g.name, Name: name, Addr: instanceAddr(cfg), Err: err}
+	}
+	g.opts.log(slog.LevelInfo, "redis client closed", g.key(name), cfg)
+	return nil
+}
+
+// key 返回实例在管理器中的标识
+func (g *group) key(name string) InstanceKey {
+	return InstanceKey{Group: g.name, Name: name}
+}
+
+// Reconfigure 使用新配置原子地替换已注册的客户端
+//
+// 先用新配置创建并Ping测试客户端，失败时保持原客户端不变；原客户端尚未创建时同样会立即创建新客户端，
+// 不会推迟到第一次Get；
+// 成功后替换配置和客户端，替换期间的Get会等待替换完成而不会返回客户端不存在；
+// 旧客户端在排空等待时间（见WithGracePeriod）后关闭，仍持有旧客户端的调用方可以完成正在执行的命令。
+func (g *group) Reconfigure(ctx context.Context, name string, cfg RedisConfig) error {
+	// 检查是否已注册，避免为不存在的客户端创建连接
+	oldCfg, err := g.Config(ctx, name)
+	if err != nil {
+		return notFoundError(OpReconfigure, g.key(name), err)
+	}
+
+	client, err := g.openClient(ctx, name, cfg)
+	if err != nil {
+		return err
+	}
+
+	var old []*redis.Client
+	g.state.mu.Lock()
+	err = g.swap(ctx, name, cfg, client, &old)
+	g.state.mu.Unlock()
+	if err != nil {
+		g.discard(client)
+		return err
+	}
+
+	g.opts.log(slog.LevelInfo, "redis client reconfigured", g.key(name), cfg, "old_addr", instanceAddr(oldCfg))
+	for _, c := range old {
+		g.drain(name, oldCfg, c)
+	}
+	return nil
+}
+
+// swap 在持有写锁时注销旧配置并注册新配置，旧客户端收集到old中
+func (g *group) swap(ctx context.Context, name string, cfg RedisConfig, client *redis.Client, old *[]*redis.Client) error {
+	if err := g.Group.Unregister(context.WithValue(ctx, detachedKey, old), name); err != nil {
+		return notFoundError(OpReconfigure, g.key(name), err)
+	}
+	if _, err := g.Group.Register(ctx, name, cfg); err != nil {
+		return err
+	}
+	return g.adopt(ctx, name, cfg, client)
+}
+
+// adopt 将已创建的客户端放入组中，客户端已存在或配置已变化时关闭传入的客户端
+func (g *group) adopt(ctx context.Context, name string, cfg RedisConfig, client *redis.Client) error {
+	p := &preopened{client: client, cfg: cfg}
+	// 配置已变化时由groupOpener重新创建，携带openInfo以安装指标等钩子
+	ctx = context.WithValue(ctx, openInfoKey, &openInfo{g: g, name: name})
+	current, err := g.Group.Get(context.WithValue(ctx, preopenedKey, p), name)
+	if !p.used {
+		g.discard(client)
+	}
+	if err != nil {
+		return notFoundError(OpGet, g.key(name), err)
+	}
+	g.state.track(name, current)
+	return nil
+}
+
+// discard 关闭未放入组中的客户端，并移除openAddr为其记录的熔断器
+func (g *group) discard(client *redis.Client) {
+	g.state.breakers.Delete(client)
+	_ = client.Close()
+}
+
+// unregisterGracefully 注销客户端，已创建的客户端在排空等待时间后关闭
+func (g *group) unregisterGracefully(ctx context.Context, name string) error {
+	var old []*redis.Client
+	g.state.mu.Lock()
+	cfg, _ := g.Group.Config(ctx, name)
+	g.state.untrack(name)
+	err := g.Group.Unregister(context.WithValue(ctx, detachedKey, &old), name)
+	g.state.mu.Unlock()
+	if err != nil {
+		return notFoundError(OpUnregister, g.key(name), err)
+	}
+
+	g.opts.log(slog.LevelInfo, "redis client unregistered", g.key(name), cfg)
+	for _, c := range old {
+		g.drain(name, cfg, c)
+	}
+	return nil
+}
+
+// drain 在排空等待时间后关闭旧客户端
+func (g *group) drain(name string, cfg RedisConfig, client *redis.Client) {
+	if g.opts.gracePeriod <= 0 {
+		_ = g.closeClient(name, cfg, client)
+		return
+	}
+	time.AfterFunc(g.opts.gracePeriod, func() {
+		_ = g.closeClient(name, cfg, client)
+	})
+}
+
+// notFoundError 将registry的组或资源不存在错误包装为Kind为ErrClientNotFound的*Error
+func notFoundError(op Op, key InstanceKey, err error) error {
+	if errors.Is(err, registry.ErrResourceNotFound) || errors.Is(err, registry.ErrGroupNotFound) {
+		return &Error{Op: op, Group: key.Group, Name: key.Name, Kind: ErrClientNotFound, Err: err}
+	}
+	return err
+}
+
+// openClient 创建客户端并安装指标等钩子
+// 设置了FailoverAddrs时依次尝试各候选地址，见openFailover
+func (g *group) openClient(ctx context.Context, name string, cfg RedisConfig) (*redis.Client, error) {
+	if len(cfg.FailoverAddrs) > 0 {
+		return g.openFailover(ctx, name, cfg)
+	}
+	return g.openAddr(ctx, name, cfg)
+}
+
+// openAddr 使用cfg.Addr创建客户端并安装指标等钩子
+func (g *group) openAddr(ctx context.Context, name string, cfg RedisConfig) (*redis.Client, error) {
+	client, err := opener(ctx, cfg)
+	err = instanceError(OpOpen, g.key(name), instanceAddr(cfg), err)
+	g.opts.logOpen(g.key(name), cfg, err)
+	if err != nil {
+		return nil, err
+	}
+	if breaker := g.opts.instrument(g.key(name), "", cfg, client); breaker != nil {
+		g.state.breakers.Store(client, breaker)
+	}
+	return client, nil
+}
+
+// groupOpener 创建客户端，上下文中携带配置一致的已创建客户端时直接返回
+func groupOpener(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
+	if p, ok := ctx.Value(preopenedKey).(*preopened); ok && configEqual(p.cfg, cfg) {
+		p.used = true
+		return p.client, nil
+	}
+	if info, ok := ctx.Value(openInfoKey).(*openInfo); ok {
+		return info.g.openClient(ctx, info.name, cfg)
+	}
+	return opener(ctx, cfg)
+}
+
+// configEqual 比较两个配置是否相同，凭据提供者按函数地址比较
+func configEqual(a, b RedisConfig) bool {
+	pa, pb := a.CredentialsProvider, b.CredentialsProvider
+	if (pa == nil) != (pb == nil) {
+		return false
+	}
+	if pa != nil && reflect.ValueOf(pa).Pointer() != reflect.ValueOf(pb).Pointer() {
+		return false
+	}
+	a.CredentialsProvider, b.CredentialsProvider = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// groupCloser 关闭客户端，上下文中携带收集器时交给收集器延迟关闭
+func groupCloser(ctx context.Context, client *redis.Client) error {
+	if old, ok := ctx.Value(detachedKey).(*[]*redis.Client); ok {
+		*old = append(*old, client)
+		return nil
+	}
+	return closer(ctx, client)
+}
+
+// manager 是Manager接口的实现
+type manager struct {
+	registry.Manager[RedisConfig, *redis.Client]
+
+	opts    *options
+	checker *healthChecker
+
+	mu     sync.Mutex
+	states map[string]*groupState
+}
+
+// Group 根据名称获取组
+func (m *manager) Group(name string) (Group, error) {
+	rg, err := m.Manager.Group(name)
+	if err != nil {
+		return nil, err
+	}
+	return newGroup(rg, name, m.opts, m.groupState(name)), nil
+}
+
+// MustGroup 根据名称获取组，如果组不存在则触发panic
+func (m *manager) MustGroup(name string) Group {
+	g, err := m.Group(name)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// groupState 返回组的共享状态，不存在时创建
+func (m *manager) groupState(name string) *groupState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[name]
+	if !ok {
+		state = &groupState{}
+		m.states[name] = state
+	}
+	return state
+}
+
+// groups 返回所有组，按组名排序
+func (m *manager) groups() []*group {
+	names := m.ListGroupNames()
+	sort.Strings(names)
+
+	groups := make([]*group, 0, len(names))
+	for _, name := range names {
+		g, err := m.Group(name)
+		if err != nil {
+			continue
+		}
+		groups = append(groups, g.(*group))
+	}
+	return groups
+}
+
+// Close 关闭所有组的所有客户端
+func (m *manager) Close(ctx context.Context) []error {
+	if m.checker != nil {
+		m.checker.stop()
+	}
+
+	closing := make(map[*redis.Client]closingClient)
+	for _, g := range m.groups() {
+		g.closing(ctx, closing)
+	}
+
+	// 持有所有组的写锁，避免关闭期间Get返回已关闭的客户端
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, state := range m.states {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+	}
+
+	var detached []*redis.Client
+	errs := m.Manager.Close(context.WithValue(ctx, detachedKey, &detached))
+	for _, state := range m.states {
+		state.reset()
+	}
+	return append(errs, closeDetached(detached, closing)...)
+}
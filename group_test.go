@@ -0,0 +1,173 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newFakeRedisNamed 启动一个对GET返回固定值的fakeRedis，用于区分连接到了哪个实例
+func newFakeRedisNamed(t *testing.T, value string) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if strings.EqualFold(args[0], "GET") {
+			return value, true
+		}
+		return nil, false
+	})
+}
+
+// TestGroupReconfigure 测试原子替换客户端
+func TestGroupReconfigure(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("替换后使用新实例", func(t *testing.T) {
+		a := newFakeRedisNamed(t, "a")
+		b := newFakeRedisNamed(t, "b")
+
+		group := New(WithGracePeriod(100 * time.Millisecond))
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "cache", RedisConfig{Addr: a.Addr()})
+		old, err := group.Get(ctx, "cache")
+		if err != nil {
+			t.Fatalf("获取客户端失败: %v", err)
+		}
+
+		if err := group.Reconfigure(ctx, "cache", RedisConfig{Addr: b.Addr(), PoolSize: 3}); err != nil {
+			t.Fatalf("Reconfigure失败: %v", err)
+		}
+
+		client, err := group.Get(ctx, "cache")
+		if err != nil {
+			t.Fatalf("获取客户端失败: %v", err)
+		}
+		if client == old {
+			t.Fatal("预期获取到新客户端")
+		}
+		if val := client.Get(ctx, "k").Val(); val != "b" {
+			t.Errorf("预期连接到新实例，实际为%q", val)
+		}
+		if cfg := group.MustConfig(ctx, "cache"); cfg.Addr != b.Addr() || cfg.PoolSize != 3 {
+			t.Errorf("配置未更新: %+v", cfg)
+		}
+
+		// 排空等待期间旧客户端仍可用
+		if val := old.Get(ctx, "k").Val(); val != "a" {
+			t.Errorf("排空期间旧客户端应仍可用，实际为%q", val)
+		}
+
+		// 排空等待结束后旧客户端被关闭
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			err := old.Ping(ctx).Err()
+			if errors.Is(err, redis.ErrClosed) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("预期旧客户端被关闭，实际得到: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+
+	t.Run("新配置无效时保持原客户端", func(t *testing.T) {
+		a := newFakeRedisNamed(t, "a")
+
+		group := New()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "cache", RedisConfig{Addr: a.Addr()})
+		old := group.MustGet(ctx, "cache")
+
+		err := group.Reconfigure(ctx, "cache", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: 1})
+		if !IsErrPingFailed(err) {
+			t.Fatalf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+		if err := group.Reconfigure(ctx, "cache", RedisConfig{}); !IsErrNoAddr(err) {
+			t.Fatalf("预期ErrNoAddr错误，实际得到: %v", err)
+		}
+
+		if client := group.MustGet(ctx, "cache"); client != old {
+			t.Error("替换失败时应保持原客户端")
+		}
+		if err := old.Ping(ctx).Err(); err != nil {
+			t.Errorf("原客户端应仍可用: %v", err)
+		}
+	})
+
+	t.Run("未注册的客户端", func(t *testing.T) {
+		group := New()
+		defer group.Close(ctx)
+
+		err := group.Reconfigure(ctx, "missing", RedisConfig{Addr: "127.0.0.1:6379"})
+		if !IsErrClientNotFound(err) {
+			t.Errorf("预期ErrClientNotFound错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("未创建的客户端重新配置后连接到新实例", func(t *testing.T) {
+		b := newFakeRedisNamed(t, "b")
+
+		group := New()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "cache", RedisConfig{Addr: "127.0.0.1:1"})
+		if err := group.Reconfigure(ctx, "cache", RedisConfig{Addr: b.Addr()}); err != nil {
+			t.Fatalf("Reconfigure失败: %v", err)
+		}
+		if val := group.MustGet(ctx, "cache").Get(ctx, "k").Val(); val != "b" {
+			t.Errorf("预期连接到新实例，实际为%q", val)
+		}
+	})
+
+	t.Run("替换期间Get不会返回客户端不存在", func(t *testing.T) {
+		a := newFakeRedisNamed(t, "a")
+		b := newFakeRedisNamed(t, "b")
+
+		manager := NewManager(WithGracePeriod(0))
+		defer manager.Close(ctx)
+		manager.AddGroup("cache")
+
+		_, _ = manager.MustGroup("cache").Register(ctx, "main", RedisConfig{Addr: a.Addr()})
+
+		var (
+			wg       sync.WaitGroup
+			stop     atomic.Bool
+			failures atomic.Int32
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// 每次都重新获取组，验证多个Group实例共享替换状态
+				for !stop.Load() {
+					if _, err := manager.MustGroup("cache").Get(ctx, "main"); err != nil {
+						failures.Add(1)
+					}
+				}
+			}()
+		}
+
+		for i := 0; i < 10; i++ {
+			addr := a.Addr()
+			if i%2 == 0 {
+				addr = b.Addr()
+			}
+			if err := manager.MustGroup("cache").Reconfigure(ctx, "main", RedisConfig{Addr: addr}); err != nil {
+				t.Errorf("Reconfigure失败: %v", err)
+			}
+		}
+		stop.Store(true)
+		wg.Wait()
+
+		if n := failures.Load(); n > 0 {
+			t.Errorf("替换期间Get失败%d次", n)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package mgredis
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultHealthCheckTimeout 健康检查单次Ping的默认超时时间
+	DefaultHealthCheckTimeout = time.Second
+
+	// DefaultHealthDegradedLatency Ping延迟超过该值时视为降级
+	DefaultHealthDegradedLatency = 200 * time.Millisecond
+
+	// DefaultHealthDownAfter 连续失败达到该次数时视为不可用
+	DefaultHealthDownAfter = 3
+)
+
+// Health 客户端健康状态
+type Health int
+
+const (
+	// HealthUnknown 客户端已创建但尚未检查
+	HealthUnknown Health = iota
+	// HealthHealthy Ping成功且延迟正常
+	HealthHealthy
+	// HealthDegraded Ping延迟过高，或连续失败次数未达到不可用阈值
+	HealthDegraded
+	// HealthDown 连续失败次数达到不可用阈值
+	HealthDown
+)
+
+// String 返回健康状态的名称
+func (h Health) String() string {
+	switch h {
+	case HealthHealthy:
+		return "healthy"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// ClientStatus 客户端的健康状态快照
+type ClientStatus struct {
+	// Group 组名
+	Group string
+	// Name 实例名
+	Name string
+	// Addr 客户端地址
+	Addr string
+
+	// Health 健康状态
+	Health Health
+	// Err 最近一次检查的错误，成功时为nil
+	Err error
+	// Latency 最近一次Ping的延迟
+	Latency time.Duration
+	// ConsecutiveFailures 连续失败次数
+	ConsecutiveFailures int
+	// CheckedAt 最近一次检查的时间
+	CheckedAt time.Time
+	// Since 进入当前健康状态的时间
+	Since time.Time
+
+	// Circuit 熔断器状态，未启用熔断时为CircuitClosed
+	Circuit CircuitState
+}
+
+// HealthHandler 健康状态变化回调，prev为变化前的状态
+type HealthHandler func(prev, cur ClientStatus)
+
+// healthOptions 健康检查选项
+type healthOptions struct {
+	interval        time.Duration
+	timeout         time.Duration
+	degradedLatency time.Duration
+	downAfter       int
+	handlers        []HealthHandler
+}
+
+// WithHealthCheck 启用后台健康检查，按interval定期Ping所有已创建的客户端
+// 只检查已创建的客户端，不会触发惰性创建；管理器Close时停止检查
+func WithHealthCheck(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.health.interval = interval
+		}
+	}
+}
+
+// WithHealthCheckTimeout 设置健康检查单次Ping的超时时间
+func WithHealthCheckTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.health.timeout = d
+		}
+	}
+}
+
+// WithHealthThresholds 设置降级延迟阈值和连续失败多少次视为不可用
+func WithHealthThresholds(degradedLatency time.Duration, downAfter int) Option {
+	return func(o *options) {
+		if degradedLatency > 0 {
+			o.health.degradedLatency = degradedLatency
+		}
+		if downAfter > 0 {
+			o.health.downAfter = downAfter
+		}
+	}
+}
+
+// WithHealthHandler 添加健康状态变化回调，可多次调用添加多个回调
+// 回调在健康检查的goroutine中同步执行，不同客户端的回调可能并发执行，不应长时间阻塞
+func WithHealthHandler(fn HealthHandler) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.health.handlers = append(o.health.handlers, fn)
+		}
+	}
+}
+
+// Status 返回组内所有已创建客户端的健康状态，按实例名排序
+func (g *group) Status() []ClientStatus {
+	return g.status()
+}
+
+// Status 返回所有组内已创建客户端的健康状态，按组名和实例名排序
+func (m *manager) Status() []ClientStatus {
+	var list []ClientStatus
+	for _, g := range m.groups() {
+		list = append(list, g.status()...)
+	}
+	return list
+}
+
+// status 返回已创建客户端的健康状态，尚未检查的客户端为HealthUnknown
+func (g *group) status() []ClientStatus {
+	g.state.healthMu.Lock()
+	health := make(map[string]ClientStatus, len(g.state.health))
+	for name, st := range g.state.health {
+		health[name] = st
+	}
+	g.state.healthMu.Unlock()
+
+	var list []ClientStatus
+	g.state.opened.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		st, ok := health[name]
+		if !ok {
+			st = ClientStatus{Group: g.name, Name: name, Addr: g.addr(name, value.(*redis.Client))}
+		}
+		st.Circuit = g.state.circuit(value.(*redis.Client))
+		list = append(list, st)
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// addr 返回客户端实际连接的地址（故障切换后为备用地址），Sentinel模式下为主节点名
+func (g *group) addr(name string, client *redis.Client) string {
+	cfg, err := g.Group.Config(context.Background(), name)
+	if err != nil {
+		return ""
+	}
+	if len(cfg.FailoverAddrs) > 0 {
+		return client.Options().Addr
+	}
+	return instanceAddr(cfg)
+}
+
+// instanceAddr 返回用于展示的实例地址，Sentinel模式下为主节点名
+func instanceAddr(cfg RedisConfig) string {
+	if cfg.IsSentinel() {
+		return cfg.MasterName
+	}
+	return cfg.Addr
+}
+
+// healthChecker 后台健康检查
+type healthChecker struct {
+	opts   healthOptions
+	groups func() []*group
+
+	once sync.Once
+	quit chan struct{}
+	done chan struct{}
+}
+
+// startHealthChecker 启动健康检查，未启用时返回nil
+func startHealthChecker(opts healthOptions, groups func() []*group) *healthChecker {
+	if opts.interval <= 0 {
+		return nil
+	}
+	c := &healthChecker{
+		opts:   opts,
+		groups: groups,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+func (c *healthChecker) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			c.checkAll()
+		}
+	}
+}
+
+// stop 停止健康检查并等待正在进行的检查结束
+func (c *healthChecker) stop() {
+	c.once.Do(func() {
+		close(c.quit)
+	})
+	<-c.done
+}
+
+// checkAll 并发检查所有已创建的客户端
+func (c *healthChecker) checkAll() {
+	var wg sync.WaitGroup
+	for _, g := range c.groups() {
+		g.state.opened.Range(func(key, value interface{}) bool {
+			wg.Add(1)
+			go func(g *group, name string, client *redis.Client) {
+				defer wg.Done()
+				c.check(g, name, client)
+			}(g, key.(string), value.(*redis.Client))
+			return true
+		})
+	}
+	wg.Wait()
+}
+
+// check 检查单个客户端并更新状态，状态变化时执行回调
+func (c *healthChecker) check(g *group, name string, client *redis.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.timeout)
+	start := time.Now()
+	err := client.Ping(ctx).Err()
+	latency := time.Since(start)
+	cancel()
+	addr := g.addr(name, client)
+
+	g.state.healthMu.Lock()
+	// 检查期间客户端可能已被注销或替换
+	if v, ok := g.state.opened.Load(name); !ok || v != client {
+		g.state.healthMu.Unlock()
+		return
+	}
+	if g.state.health == nil {
+		g.state.health = make(map[string]ClientStatus)
+	}
+	prev, ok := g.state.health[name]
+	if !ok {
+		prev = ClientStatus{Group: g.name, Name: name, Addr: addr}
+	}
+	cur := c.next(prev, err, latency, start)
+	cur.Addr = addr
+	cur.Circuit = g.state.circuit(client)
+	g.state.health[name] = cur
+	g.state.healthMu.Unlock()
+
+	if cur.Health != prev.Health {
+		for _, fn := range c.opts.handlers {
+			fn(prev, cur)
+		}
+	}
+}
+
+// next 根据检查结果计算新的状态
+func (c *healthChecker) next(prev ClientStatus, err error, latency time.Duration, at time.Time) ClientStatus {
+	cur := prev
+	cur.Err = err
+	cur.Latency = latency
+	cur.CheckedAt = at
+
+	switch {
+	case err != nil:
+		cur.ConsecutiveFailures++
+		if cur.ConsecutiveFailures >= c.opts.downAfter {
+			cur.Health = HealthDown
+		} else {
+			cur.Health = HealthDegraded
+		}
+	case latency > c.opts.degradedLatency:
+		cur.ConsecutiveFailures = 0
+		cur.Health = HealthDegraded
+	default:
+		cur.ConsecutiveFailures = 0
+		cur.Health = HealthHealthy
+	}
+
+	if cur.Health != prev.Health {
+		cur.Since = at
+	}
+	return cur
+}
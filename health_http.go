@@ -0,0 +1,226 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultHealthHTTPTimeout 健康检查接口Ping单个实例的默认超时时间
+const DefaultHealthHTTPTimeout = 2 * time.Second
+
+const (
+	// HealthReportOK 所有关键实例可用
+	HealthReportOK = "ok"
+	// HealthReportFail 至少一个关键实例不可用
+	HealthReportFail = "fail"
+
+	// healthNotOpened 尚未创建客户端的非关键实例在报告中的错误信息
+	healthNotOpened = "not opened"
+)
+
+// HealthReport 健康检查接口返回的JSON报告
+type HealthReport struct {
+	// Status 整体状态，ok或fail
+	Status string `json:"status"`
+
+	// Instances 各实例的检查结果，按组名和实例名排序
+	Instances []InstanceReport `json:"instances"`
+}
+
+// InstanceReport 单个实例的检查结果
+type InstanceReport struct {
+	// Group 组名
+	Group string `json:"group"`
+	// Name 实例名
+	Name string `json:"name"`
+	// ConfigName RedisConfig.Name
+	ConfigName string `json:"config_name,omitempty"`
+	// Addr 实例地址，Sentinel模式下为主节点名
+	Addr string `json:"addr"`
+	// Latency Ping延迟，如"1.2ms"
+	Latency string `json:"latency"`
+	// Error 失败原因，成功时为空
+	Error string `json:"error,omitempty"`
+	// Healthy 是否可用
+	Healthy bool `json:"healthy"`
+	// Opened 客户端是否已创建，关键实例会在检查时创建，未创建的非关键实例不会被检查
+	Opened bool `json:"opened"`
+	// Critical 是否为关键实例，非关键实例不可用时不影响整体状态
+	Critical bool `json:"critical"`
+}
+
+// HealthHTTPOption 健康检查接口选项
+type HealthHTTPOption func(*healthHTTPHandler)
+
+// WithHealthHTTPTimeout 设置Ping单个实例的超时时间
+func WithHealthHTTPTimeout(d time.Duration) HealthHTTPOption {
+	return func(h *healthHTTPHandler) {
+		if d > 0 {
+			h.timeout = d
+		}
+	}
+}
+
+// WithNonCritical 将实例标记为非关键实例，未指定names时整个组都是非关键的
+// 非关键实例仍会被检查并出现在报告中，但不可用时接口仍返回200
+func WithNonCritical(group string, names ...string) HealthHTTPOption {
+	return func(h *healthHTTPHandler) {
+		if len(names) == 0 {
+			h.nonCritical[InstanceKey{Group: group}] = true
+			return
+		}
+		for _, name := range names {
+			h.nonCritical[InstanceKey{Group: group, Name: name}] = true
+		}
+	}
+}
+
+// healthHTTPHandler 健康检查接口
+type healthHTTPHandler struct {
+	manager     Manager
+	timeout     time.Duration
+	nonCritical map[InstanceKey]bool
+}
+
+// NewHealthHTTPHandler 创建健康检查接口，可用于/healthz或/readyz
+//
+// 每次请求并发Ping管理器中的所有实例，所有关键实例可用时返回200，否则返回503，
+// 响应体为JSON格式的HealthReport。尚未创建的关键实例会在请求的上下文和超时内创建并Ping，
+// 创建失败时视为不可用；尚未创建的非关键实例报告为"not opened"，不会在接口中创建连接。
+func NewHealthHTTPHandler(manager Manager, opts ...HealthHTTPOption) http.Handler {
+	h := &healthHTTPHandler{
+		manager:     manager,
+		timeout:     DefaultHealthHTTPTimeout,
+		nonCritical: make(map[InstanceKey]bool),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP 执行检查并返回报告
+func (h *healthHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == HealthReportOK {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// check 并发检查所有实例
+func (h *healthHTTPHandler) check(ctx context.Context) HealthReport {
+	var keys []InstanceKey
+	for _, groupName := range h.manager.ListGroupNames() {
+		g, err := h.manager.Group(groupName)
+		if err != nil {
+			continue
+		}
+		for _, name := range g.List() {
+			keys = append(keys, InstanceKey{Group: groupName, Name: name})
+		}
+	}
+	sortInstanceKeys(keys)
+
+	report := HealthReport{Status: HealthReportOK, Instances: make([]InstanceReport, len(keys))}
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key InstanceKey) {
+			defer wg.Done()
+			report.Instances[i] = h.checkInstance(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	for _, ir := range report.Instances {
+		if ir.Critical && !ir.Healthy {
+			report.Status = HealthReportFail
+		}
+	}
+	return report
+}
+
+// checkInstance 获取客户端并Ping，关键实例未创建时创建
+func (h *healthHTTPHandler) checkInstance(ctx context.Context, key InstanceKey) InstanceReport {
+	ir := InstanceReport{
+		Group:    key.Group,
+		Name:     key.Name,
+		Critical: !h.nonCritical[InstanceKey{Group: key.Group}] && !h.nonCritical[key],
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.ping(ctx, key, &ir)
+	ir.Latency = time.Since(start).String()
+	if err != nil {
+		ir.Error = err.Error()
+		return ir
+	}
+	ir.Healthy = true
+	return ir
+}
+
+func (h *healthHTTPHandler) ping(ctx context.Context, key InstanceKey, ir *InstanceReport) error {
+	g, err := h.manager.Group(key.Group)
+	if err != nil {
+		return notFoundError(OpGet, key, err)
+	}
+	cfg, err := g.Config(ctx, key.Name)
+	if err != nil {
+		return notFoundError(OpGet, key, err)
+	}
+	ir.ConfigName = cfg.Name
+	ir.Addr = instanceAddr(cfg)
+
+	if ir.Critical {
+		// 关键实例未创建或惰性创建一直失败时同样视为不可用
+		client, err := g.Get(ctx, key.Name)
+		if err != nil {
+			return err
+		}
+		ir.Opened = true
+		return client.Ping(ctx).Err()
+	}
+
+	client, ok, err := openedClient(ctx, g, key.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New(healthNotOpened)
+	}
+	ir.Opened = true
+	return client.Ping(ctx).Err()
+}
+
+// openedClient 返回已创建的客户端，不会触发惰性创建
+// 由mgredis创建的组直接读取已创建的客户端，避免在注册表锁内建立连接
+func openedClient(ctx context.Context, g Group, name string) (*redis.Client, bool, error) {
+	gg, ok := g.(*group)
+	if !ok {
+		client, err := g.Get(ctx, name)
+		return client, err == nil, err
+	}
+	client, ok := gg.state.opened.Load(name)
+	if !ok {
+		return nil, false, nil
+	}
+	return client.(*redis.Client), true, nil
+}
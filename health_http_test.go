@@ -0,0 +1,152 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestHealthHTTPHandler 测试健康检查接口
+func TestHealthHTTPHandler(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedis(t, nil)
+	var failing atomic.Bool
+	flaky := newFlakyRedis(t, &failing, nil)
+
+	manager := NewManager()
+	defer manager.Close(ctx)
+	manager.AddGroup("cache")
+	manager.AddGroup("stats")
+	_, _ = manager.MustGroup("cache").Register(ctx, "main", RedisConfig{Name: "主缓存", Addr: s.Addr()})
+	_, _ = manager.MustGroup("stats").Register(ctx, "a", RedisConfig{Addr: flaky.Addr(), MaxRetries: -1})
+	_, _ = manager.MustGroup("stats").Register(ctx, "b", RedisConfig{Addr: flaky.Addr(), MaxRetries: -1})
+	_, _ = manager.MustGroup("stats").Register(ctx, "lazy", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: -1})
+	manager.MustGroup("cache").MustGet(ctx, "main")
+	manager.MustGroup("stats").MustGet(ctx, "a")
+	manager.MustGroup("stats").MustGet(ctx, "b")
+	failing.Store(true)
+
+	serve := func(h http.Handler, method string) (*httptest.ResponseRecorder, HealthReport) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/healthz", nil))
+		var report HealthReport
+		if method != http.MethodHead {
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("解析报告失败: %v, body=%s", err, rec.Body.String())
+			}
+		}
+		return rec, report
+	}
+
+	t.Run("关键实例不可用", func(t *testing.T) {
+		h := NewHealthHTTPHandler(manager, WithNonCritical("stats", "a"), WithHealthHTTPTimeout(time.Second))
+		rec, report := serve(h, http.MethodGet)
+		if rec.Code != http.StatusServiceUnavailable || report.Status != HealthReportFail {
+			t.Fatalf("预期503，实际为%d %s", rec.Code, report.Status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type错误: %s", ct)
+		}
+		if len(report.Instances) != 4 {
+			t.Fatalf("预期4个实例，实际为%+v", report.Instances)
+		}
+
+		main := report.Instances[0]
+		if main.Group != "cache" || main.Name != "main" || main.ConfigName != "主缓存" ||
+			main.Addr != s.Addr() || !main.Healthy || !main.Opened || !main.Critical || main.Error != "" || main.Latency == "" {
+			t.Errorf("cache/main结果错误: %+v", main)
+		}
+		a, b := report.Instances[1], report.Instances[2]
+		if a.Name != "a" || a.Critical || a.Healthy || a.Error == "" {
+			t.Errorf("stats/a结果错误: %+v", a)
+		}
+		if b.Name != "b" || !b.Critical || b.Healthy {
+			t.Errorf("stats/b结果错误: %+v", b)
+		}
+	})
+
+	t.Run("未创建的非关键实例不会被连接", func(t *testing.T) {
+		h := NewHealthHTTPHandler(manager, WithNonCritical("stats", "a", "b", "lazy"))
+		rec, report := serve(h, http.MethodGet)
+		if rec.Code != http.StatusOK || report.Status != HealthReportOK {
+			t.Errorf("未创建的非关键实例不应影响整体状态: %d %s", rec.Code, report.Status)
+		}
+		lazy := report.Instances[3]
+		if lazy.Name != "lazy" || lazy.Opened || lazy.Healthy || lazy.Error != healthNotOpened || lazy.Addr != "127.0.0.1:1" {
+			t.Errorf("stats/lazy结果错误: %+v", lazy)
+		}
+		if len(manager.MustGroup("stats").Status()) != 2 {
+			t.Error("健康检查接口不应创建非关键实例的客户端")
+		}
+	})
+
+	t.Run("未创建的关键实例", func(t *testing.T) {
+		h := NewHealthHTTPHandler(manager, WithNonCritical("stats", "a", "b"))
+		rec, report := serve(h, http.MethodGet)
+		if rec.Code != http.StatusServiceUnavailable || report.Status != HealthReportFail {
+			t.Errorf("无法创建的关键实例应视为不可用: %d %s", rec.Code, report.Status)
+		}
+		lazy := report.Instances[3]
+		if lazy.Name != "lazy" || lazy.Opened || lazy.Healthy || lazy.Error == "" || lazy.Error == healthNotOpened {
+			t.Errorf("stats/lazy应报告创建失败的原因: %+v", lazy)
+		}
+
+		manager.AddGroup("cold")
+		_, _ = manager.MustGroup("cold").Register(ctx, "main", RedisConfig{Addr: s.Addr()})
+		_, report = serve(NewHealthHTTPHandler(manager, WithNonCritical("stats")), http.MethodGet)
+		cold := report.Instances[1]
+		if cold.Group != "cold" || !cold.Opened || !cold.Healthy || report.Status != HealthReportOK {
+			t.Errorf("未创建的关键实例应被创建并检查: %s %+v", report.Status, cold)
+		}
+		if len(manager.MustGroup("cold").Status()) != 1 {
+			t.Error("关键实例的客户端应被创建")
+		}
+	})
+
+	t.Run("非关键组不影响整体状态", func(t *testing.T) {
+		h := NewHealthHTTPHandler(manager, WithNonCritical("stats"))
+		rec, report := serve(h, http.MethodGet)
+		if rec.Code != http.StatusOK || report.Status != HealthReportOK {
+			t.Errorf("预期200，实际为%d %s", rec.Code, report.Status)
+		}
+	})
+
+	t.Run("HEAD请求", func(t *testing.T) {
+		h := NewHealthHTTPHandler(manager)
+		rec, _ := serve(h, http.MethodHead)
+		if rec.Code != http.StatusServiceUnavailable || rec.Body.Len() != 0 {
+			t.Errorf("HEAD请求应只返回状态码: %d %q", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestHealthHTTPHandlerTimeout 测试服务端不回复时接口在超时时间内返回
+func TestHealthHTTPHandlerTimeout(t *testing.T) {
+	ctx := context.Background()
+	var hang atomic.Bool
+	s := newHangingRedis(t, &hang)
+
+	manager := NewManager()
+	defer manager.Close(ctx)
+	manager.AddGroup("cache")
+	_, _ = manager.MustGroup("cache").Register(ctx, "main", RedisConfig{Addr: s.Addr(), ReadTimeout: 10 * time.Second})
+	// 未创建的关键实例在接口中创建，创建同样受超时限制
+	_, _ = manager.MustGroup("cache").Register(ctx, "cold", RedisConfig{Addr: s.Addr(), ReadTimeout: 10 * time.Second})
+	manager.MustGroup("cache").MustGet(ctx, "main")
+	hang.Store(true)
+
+	h := NewHealthHTTPHandler(manager, WithHealthHTTPTimeout(300*time.Millisecond))
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("预期在超时时间内返回，实际耗时%v", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("预期503，实际为%d", rec.Code)
+	}
+}
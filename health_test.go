@@ -0,0 +1,216 @@
+package mgredis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyRedis 返回可切换PING失败和延迟的fakeRedis
+func newFlakyRedis(t *testing.T, failing *atomic.Bool, delay *atomic.Int64) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if !strings.EqualFold(args[0], "PING") {
+			return nil, false
+		}
+		if delay != nil {
+			time.Sleep(time.Duration(delay.Load()))
+		}
+		if failing.Load() {
+			return respError("ERR injected failure"), true
+		}
+		return nil, false
+	})
+}
+
+// newHangingRedis 返回hang为true时接受命令但从不回复的fakeRedis
+func newHangingRedis(t *testing.T, hang *atomic.Bool) *fakeRedis {
+	release := make(chan struct{})
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if hang.Load() {
+			<-release
+		}
+		return nil, false
+	})
+	// 先于fakeRedis的Close执行，释放阻塞的连接
+	t.Cleanup(func() { close(release) })
+	return s
+}
+
+// waitHealth 等待实例达到指定健康状态
+func waitHealth(t *testing.T, status func() []ClientStatus, name string, want Health) ClientStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, st := range status() {
+			if st.Name == name && st.Health == want {
+				return st
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("等待%s变为%s超时: %+v", name, want, status())
+	return ClientStatus{}
+}
+
+// TestHealthCheck 测试健康状态变化及回调
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	var failing atomic.Bool
+	s := newFlakyRedis(t, &failing, nil)
+
+	var mu sync.Mutex
+	var changes []string
+	group := New(
+		WithHealthCheck(10*time.Millisecond),
+		WithHealthThresholds(time.Second, 2),
+		WithHealthHandler(func(prev, cur ClientStatus) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, prev.Health.String()+"->"+cur.Health.String())
+		}),
+	)
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: s.Addr(), MaxRetries: -1})
+	_, _ = group.Register(ctx, "lazy", RedisConfig{Addr: s.Addr()})
+	group.MustGet(ctx, "cache")
+
+	st := waitHealth(t, group.Status, "cache", HealthHealthy)
+	if st.Group != defaultGroupName || st.Addr != s.Addr() || st.Err != nil || st.CheckedAt.IsZero() {
+		t.Errorf("状态错误: %+v", st)
+	}
+
+	failing.Store(true)
+	st = waitHealth(t, group.Status, "cache", HealthDown)
+	if st.ConsecutiveFailures < 2 || st.Err == nil || !strings.Contains(st.Err.Error(), "injected failure") {
+		t.Errorf("状态错误: %+v", st)
+	}
+
+	failing.Store(false)
+	st = waitHealth(t, group.Status, "cache", HealthHealthy)
+	if st.ConsecutiveFailures != 0 || st.Err != nil {
+		t.Errorf("恢复后状态错误: %+v", st)
+	}
+
+	// 未创建的客户端不会被检查
+	for _, st := range group.Status() {
+		if st.Name == "lazy" {
+			t.Error("未创建的客户端不应出现在状态中")
+		}
+	}
+
+	mu.Lock()
+	got := strings.Join(changes, ",")
+	mu.Unlock()
+	if got != "unknown->healthy,healthy->degraded,degraded->down,down->healthy" {
+		t.Errorf("状态变化回调错误: %s", got)
+	}
+
+	if err := group.Unregister(ctx, "cache"); err != nil {
+		t.Fatalf("注销失败: %v", err)
+	}
+	if len(group.Status()) != 0 {
+		t.Errorf("注销后不应保留状态: %+v", group.Status())
+	}
+}
+
+// TestHealthCheckLatency 测试延迟过高时降级
+func TestHealthCheckLatency(t *testing.T) {
+	ctx := context.Background()
+	var failing atomic.Bool
+	var delay atomic.Int64
+	s := newFlakyRedis(t, &failing, &delay)
+
+	group := New(WithHealthCheck(10*time.Millisecond), WithHealthThresholds(20*time.Millisecond, 3))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: s.Addr()})
+	group.MustGet(ctx, "cache")
+	waitHealth(t, group.Status, "cache", HealthHealthy)
+
+	delay.Store(int64(40 * time.Millisecond))
+	st := waitHealth(t, group.Status, "cache", HealthDegraded)
+	if st.Err != nil || st.Latency < 20*time.Millisecond {
+		t.Errorf("状态错误: %+v", st)
+	}
+}
+
+// TestManagerStatus 测试多组健康状态及关闭后停止检查
+func TestManagerStatus(t *testing.T) {
+	ctx := context.Background()
+	var failing atomic.Bool
+	s := newFlakyRedis(t, &failing, nil)
+
+	manager := NewManager(WithHealthCheck(10 * time.Millisecond))
+	for _, groupName := range []string{"session", "cache"} {
+		manager.AddGroup(groupName)
+		_, _ = manager.MustGroup(groupName).Register(ctx, "main", RedisConfig{Addr: s.Addr()})
+		manager.MustGroup(groupName).MustGet(ctx, "main")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		list := manager.Status()
+		if len(list) == 2 && list[0].Health == HealthHealthy && list[1].Health == HealthHealthy {
+			if list[0].Group != "cache" || list[1].Group != "session" {
+				t.Errorf("状态应按组名排序: %+v", list)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("等待健康检查超时: %+v", list)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	manager.Close(ctx)
+	if len(manager.Status()) != 0 {
+		t.Errorf("关闭后不应保留状态: %+v", manager.Status())
+	}
+	pings := countCommand(s, "PING")
+	time.Sleep(50 * time.Millisecond)
+	if countCommand(s, "PING") != pings {
+		t.Error("关闭后应停止健康检查")
+	}
+}
+
+func countCommand(s *fakeRedis, name string) int {
+	n := 0
+	for _, cmd := range s.Commands() {
+		if cmd == name {
+			n++
+		}
+	}
+	return n
+}
+
+// TestHealthCheckTimeout 测试服务端不回复时健康检查在超时时间内完成
+func TestHealthCheckTimeout(t *testing.T) {
+	ctx := context.Background()
+	var hang atomic.Bool
+	s := newHangingRedis(t, &hang)
+
+	group := New(
+		WithHealthCheck(20*time.Millisecond),
+		WithHealthCheckTimeout(100*time.Millisecond),
+		WithHealthThresholds(time.Second, 1),
+	)
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: s.Addr(), ReadTimeout: 10 * time.Second})
+	group.MustGet(ctx, "cache")
+	waitHealth(t, group.Status, "cache", HealthHealthy)
+
+	hang.Store(true)
+	start := time.Now()
+	st := waitHealth(t, group.Status, "cache", HealthDown)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("健康检查应在超时时间内完成，实际耗时%v", elapsed)
+	}
+	if st.Err == nil {
+		t.Errorf("状态错误: %+v", st)
+	}
+}
@@ -0,0 +1,259 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManagerConfig 多组管理器配置，对应配置文件的顶层结构
+//
+// JSON示例:
+//
+//	{
+//	  "groups": {
+//	    "session-cache": {
+//	      "defaults": {"pool_size": 5, "read_timeout": "1s"},
+//	      "instances": {
+//	        "primary": {"name": "会话缓存", "addr": "127.0.0.1:6379", "db": 1}
+//	      }
+//	    }
+//	  }
+//	}
+type ManagerConfig struct {
+	// Groups 组名 => 组配置
+	Groups map[string]GroupConfig `json:"groups"`
+}
+
+// GroupConfig 单个组的配置
+type GroupConfig struct {
+	// Defaults 组级默认配置，实例中未设置的字段使用此处的值
+	// 从JSON或YAML解析时按字段是否出现合并，实例中显式设置的false和0同样会覆盖默认值；
+	// 直接构造的GroupConfig无法区分未设置和零值，只有零值字段使用默认值
+	Defaults RedisConfig `json:"defaults"`
+
+	// Instances 实例名 => 实例配置
+	Instances map[string]RedisConfig `json:"instances"`
+
+	// merged Instances已在解析时合并Defaults
+	merged bool
+}
+
+// UnmarshalJSON 解析组配置，在原始文档层面将defaults合并到每个实例
+func (gc *GroupConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Defaults  json.RawMessage            `json:"defaults"`
+		Instances map[string]json.RawMessage `json:"instances"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*gc = GroupConfig{merged: true}
+	if len(raw.Defaults) > 0 {
+		if err := json.Unmarshal(raw.Defaults, &gc.Defaults); err != nil {
+			return err
+		}
+	}
+	if raw.Instances == nil {
+		return nil
+	}
+	gc.Instances = make(map[string]RedisConfig, len(raw.Instances))
+	for name, doc := range raw.Instances {
+		var cfg RedisConfig
+		if err := json.Unmarshal(mergeRawDefaults(doc, raw.Defaults), &cfg); err != nil {
+			return fmt.Errorf("instance %q: %w", name, err)
+		}
+		gc.Instances[name] = cfg
+	}
+	return nil
+}
+
+// LoadManagerConfig 从JSON或YAML文件加载管理器配置
+// 扩展名为.yaml或.yml时按YAML解析，其余按JSON解析
+func LoadManagerConfig(path string) (ManagerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ManagerConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return parseManagerConfig(path, data)
+}
+
+// parseManagerConfig 根据文件扩展名解析配置内容
+func parseManagerConfig(path string, data []byte) (ManagerConfig, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseManagerConfigYAML(data)
+	default:
+		return ParseManagerConfigJSON(data)
+	}
+}
+
+// ParseManagerConfigJSON 解析JSON格式的管理器配置
+func ParseManagerConfigJSON(data []byte) (ManagerConfig, error) {
+	var cfg ManagerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return ManagerConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return cfg, nil
+}
+
+// ParseManagerConfigYAML 解析YAML格式的管理器配置，字段名与JSON相同
+func ParseManagerConfigYAML(data []byte) (ManagerConfig, error) {
+	// 先转换为JSON，复用json标签和时长解析
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return ManagerConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return ManagerConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return ParseManagerConfigJSON(data)
+}
+
+// Configs 返回合并组级默认配置后的实例配置，组名 => 实例名 => 配置
+func (mc ManagerConfig) Configs() map[string]map[string]RedisConfig {
+	configs := make(map[string]map[string]RedisConfig, len(mc.Groups))
+	for groupName, gc := range mc.Groups {
+		instances := make(map[string]RedisConfig, len(gc.Instances))
+		for name, cfg := range gc.Instances {
+			if !gc.merged {
+				cfg = mergeDefaults(cfg, gc.Defaults)
+			}
+			instances[name] = cfg
+		}
+		configs[groupName] = instances
+	}
+	return configs
+}
+
+// Validate 检查所有实例配置，一次性返回全部无效条目
+// 返回的错误可通过errors.Is判断ErrInvalidConfig及具体原因（如ErrNoAddr）
+func (mc ManagerConfig) Validate() error {
+	var errs []error
+	configs := mc.Configs()
+	for _, groupName := range sortedKeys(configs) {
+		if groupName == "" {
+			errs = append(errs, fmt.Errorf("%w: empty group name", ErrInvalidConfig))
+		}
+		instances := configs[groupName]
+		for _, name := range sortedKeys(instances) {
+			if name == "" {
+				errs = append(errs, fmt.Errorf("%w: group %q: empty instance name", ErrInvalidConfig, groupName))
+				continue
+			}
+			cfg := instances[name]
+			if err := cfg.CheckAndSetDefaults(); err != nil {
+				errs = append(errs, fmt.Errorf("%w: group %q instance %q: %w", ErrInvalidConfig, groupName, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadManager 从配置文件创建多组管理器，opts传给NewManager
+func LoadManager(path string, opts ...Option) (Manager, error) {
+	cfg, err := LoadManagerConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerFromConfig(cfg, opts...)
+}
+
+// NewManagerFromConfig 根据配置创建多组管理器并注册所有实例，opts传给NewManager
+// 配置无效时返回包含所有无效条目的错误，不会创建管理器
+func NewManagerFromConfig(cfg ManagerConfig, opts ...Option) (Manager, error) {
+	manager := NewManager(opts...)
+	if err := RegisterManagerConfig(context.Background(), manager, cfg); err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// RegisterManagerConfig 将配置中的组和实例注册到已有的管理器
+// 先校验全部配置，配置无效时不注册任何实例；同名实例已存在时保持原配置
+func RegisterManagerConfig(ctx context.Context, manager Manager, cfg ManagerConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	for groupName, instances := range cfg.Configs() {
+		manager.AddGroup(groupName)
+		group := manager.MustGroup(groupName)
+		for name, rc := range instances {
+			if _, err := group.Register(ctx, name, rc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeDefaults 用defaults填充cfg中的零值字段，嵌套结构体逐字段合并
+func mergeDefaults(cfg, defaults RedisConfig) RedisConfig {
+	mergeZeroFields(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(defaults))
+	return cfg
+}
+
+func mergeZeroFields(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		if field.Kind() == reflect.Struct {
+			mergeZeroFields(field, src.Field(i))
+			continue
+		}
+		if field.IsZero() {
+			field.Set(src.Field(i))
+		}
+	}
+}
+
+// mergeRawDefaults 将defaults中实例未出现的字段补充到实例文档，嵌套对象逐字段合并
+// 字段名按小写比较，与encoding/json的匹配规则一致
+func mergeRawDefaults(doc, defaults json.RawMessage) json.RawMessage {
+	var dst, src map[string]json.RawMessage
+	if json.Unmarshal(doc, &dst) != nil || json.Unmarshal(defaults, &src) != nil || src == nil {
+		return doc
+	}
+	if dst == nil {
+		// 实例为null时等同于空对象
+		dst = make(map[string]json.RawMessage, len(src))
+	}
+
+	keys := make(map[string]string, len(dst))
+	for k := range dst {
+		keys[strings.ToLower(k)] = k
+	}
+	for k, v := range src {
+		if existing, ok := keys[strings.ToLower(k)]; ok {
+			dst[existing] = mergeRawDefaults(dst[existing], v)
+			continue
+		}
+		dst[k] = v
+	}
+
+	merged, err := json.Marshal(dst)
+	if err != nil {
+		return doc
+	}
+	return merged
+}
+
+// sortedKeys 返回排序后的map键，保证错误顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,249 @@
+package mgredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile 在临时目录写入配置文件并返回路径
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	return path
+}
+
+// TestRedisConfigUnmarshalJSON 测试时长字段的JSON解析
+func TestRedisConfigUnmarshalJSON(t *testing.T) {
+	var cfg RedisConfig
+	data := `{"addr":"127.0.0.1:6379","dial_timeout":"2s","read_timeout":1,"idle_timeout":"1m30s","tls":{"enabled":true}}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if cfg.DialTimeout != 2*time.Second || cfg.ReadTimeout != time.Second || cfg.IdleTimeout != 90*time.Second {
+		t.Errorf("时长解析错误: %+v", cfg)
+	}
+	if cfg.Addr != "127.0.0.1:6379" || !cfg.TLS.Enabled {
+		t.Errorf("普通字段解析错误: %+v", cfg)
+	}
+
+	if err := json.Unmarshal([]byte(`{"read_timeout":"soon"}`), &cfg); err == nil {
+		t.Error("预期无效时长返回错误")
+	}
+	if err := json.Unmarshal([]byte(`{"read_timeout":1.5}`), &cfg); err == nil {
+		t.Error("预期小数时长返回错误")
+	}
+
+	t.Run("整数时长与URL和环境变量一致", func(t *testing.T) {
+		var fromJSON RedisConfig
+		if err := json.Unmarshal([]byte(`{"addr":"127.0.0.1:6379","read_timeout":3}`), &fromJSON); err != nil {
+			t.Fatalf("解析JSON失败: %v", err)
+		}
+		fromURL, err := ParseURL("redis://127.0.0.1:6379?read_timeout=3")
+		if err != nil {
+			t.Fatalf("ParseURL失败: %v", err)
+		}
+		fromEnv, err := ParseEnv("", []string{"MGREDIS_CACHE_MAIN_READ_TIMEOUT=3"})
+		if err != nil {
+			t.Fatalf("ParseEnv失败: %v", err)
+		}
+		envTimeout := fromEnv.Groups["cache"].Instances["main"].ReadTimeout
+		if fromJSON.ReadTimeout != 3*time.Second || fromURL.ReadTimeout != 3*time.Second || envTimeout != 3*time.Second {
+			t.Errorf("预期均为3s，JSON=%v URL=%v 环境变量=%v", fromJSON.ReadTimeout, fromURL.ReadTimeout, envTimeout)
+		}
+	})
+}
+
+// TestLoadManager 测试从配置文件创建管理器
+func TestLoadManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("JSON配置", func(t *testing.T) {
+		path := writeConfigFile(t, "redis.json", `{
+			"groups": {
+				"session-cache": {
+					"defaults": {"pool_size": 5, "read_timeout": "1s"},
+					"instances": {
+						"primary": {"name": "会话缓存", "addr": "127.0.0.1:6379", "db": 1},
+						"backup": {"addr": "127.0.0.1:6380", "pool_size": 8}
+					}
+				},
+				"rate-limiter": {
+					"instances": {"primary": {"addr": "127.0.0.1:6379", "db": 2}}
+				}
+			}
+		}`)
+
+		manager, err := LoadManager(path)
+		if err != nil {
+			t.Fatalf("LoadManager失败: %v", err)
+		}
+		defer manager.Close(ctx)
+
+		names := manager.ListGroupNames()
+		sort.Strings(names)
+		if len(names) != 2 || names[0] != "rate-limiter" || names[1] != "session-cache" {
+			t.Fatalf("组名错误: %v", names)
+		}
+
+		group := manager.MustGroup("session-cache")
+		primary := group.MustConfig(ctx, "primary")
+		if primary.PoolSize != 5 || primary.ReadTimeout != time.Second || primary.DB != 1 || primary.Name != "会话缓存" {
+			t.Errorf("primary配置错误: %+v", primary)
+		}
+		backup := group.MustConfig(ctx, "backup")
+		if backup.PoolSize != 8 || backup.ReadTimeout != time.Second {
+			t.Errorf("实例配置应覆盖组默认值: %+v", backup)
+		}
+	})
+
+	t.Run("YAML配置", func(t *testing.T) {
+		path := writeConfigFile(t, "redis.yaml", `
+groups:
+  cache:
+    defaults:
+      dial_timeout: 2s
+      password: secret
+    instances:
+      main:
+        addr: 127.0.0.1:6379
+      sentinel:
+        master_name: mymaster
+        sentinel_addrs: ["127.0.0.1:26379"]
+`)
+
+		manager, err := LoadManager(path)
+		if err != nil {
+			t.Fatalf("LoadManager失败: %v", err)
+		}
+		defer manager.Close(ctx)
+
+		cfg := manager.MustGroup("cache").MustConfig(ctx, "main")
+		if cfg.DialTimeout != 2*time.Second || cfg.Password != "secret" {
+			t.Errorf("YAML配置解析错误: %+v", cfg)
+		}
+		cfg = manager.MustGroup("cache").MustConfig(ctx, "sentinel")
+		if !cfg.IsSentinel() || len(cfg.SentinelAddrs) != 1 {
+			t.Errorf("YAML Sentinel配置解析错误: %+v", cfg)
+		}
+	})
+
+	t.Run("显式零值覆盖组默认值", func(t *testing.T) {
+		path := writeConfigFile(t, "redis.yaml", `
+groups:
+  cache:
+    defaults:
+      db: 3
+      replica_only: true
+      tls: {enabled: true, server_name: redis.local}
+      failover: {failback: true}
+    instances:
+      plain:
+        addr: 127.0.0.1:6379
+        db: 0
+        replica_only: false
+        tls: {enabled: false}
+        failover: {failback: false}
+      inherit: {addr: 127.0.0.1:6380}
+      empty:
+`)
+		cfg, err := LoadManagerConfig(path)
+		if err != nil {
+			t.Fatalf("LoadManagerConfig失败: %v", err)
+		}
+		configs := cfg.Configs()["cache"]
+		plain := configs["plain"]
+		if plain.DB != 0 || plain.ReplicaOnly || plain.TLS.Enabled || plain.Failover.Failback {
+			t.Errorf("显式设置的零值应覆盖组默认值: %+v", plain)
+		}
+		if plain.TLS.ServerName != "redis.local" {
+			t.Errorf("嵌套对象中未设置的字段应使用默认值: %+v", plain.TLS)
+		}
+		inherit := configs["inherit"]
+		if inherit.DB != 3 || !inherit.ReplicaOnly || !inherit.TLS.Enabled || !inherit.Failover.Failback {
+			t.Errorf("未设置的字段应使用组默认值: %+v", inherit)
+		}
+		if empty := configs["empty"]; empty.DB != 3 || !empty.TLS.Enabled {
+			t.Errorf("空实例应使用组默认值: %+v", empty)
+		}
+	})
+
+	t.Run("一次报告所有无效条目", func(t *testing.T) {
+		path := writeConfigFile(t, "redis.json", `{
+			"groups": {
+				"a": {"instances": {"no-addr": {"db": 1}, "ok": {"addr": "127.0.0.1:6379"}}},
+				"b": {"instances": {"no-sentinel": {"master_name": "mymaster"}}},
+				"c": {"instances": {"bad-tls": {"addr": "127.0.0.1:6379", "tls": {"enabled": true, "min_version": "2.0"}}}}
+			}
+		}`)
+
+		_, err := LoadManager(path)
+		if !IsErrInvalidConfig(err) {
+			t.Fatalf("预期ErrInvalidConfig错误，实际得到: %v", err)
+		}
+		if !IsErrNoAddr(err) || !IsErrNoSentinelAddrs(err) || !IsErrInvalidTLSConfig(err) {
+			t.Errorf("预期包含所有无效条目，实际得到: %v", err)
+		}
+
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) || len(joined.Unwrap()) != 3 {
+			t.Errorf("预期3个错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("格式错误", func(t *testing.T) {
+		path := writeConfigFile(t, "redis.json", `{"groups": {"a": {"instances": {"x": {"read_timeout": "soon"}}}}}`)
+		if _, err := LoadManager(path); !IsErrInvalidConfig(err) {
+			t.Errorf("预期ErrInvalidConfig错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		if _, err := LoadManager(filepath.Join(t.TempDir(), "missing.json")); !IsErrInvalidConfig(err) {
+			t.Errorf("预期ErrInvalidConfig错误，实际得到: %v", err)
+		}
+	})
+}
+
+// TestNewManagerFromConfig 测试根据配置结构创建管理器
+func TestNewManagerFromConfig(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t, nil)
+	metrics := NewMetrics()
+
+	manager, err := NewManagerFromConfig(ManagerConfig{
+		Groups: map[string]GroupConfig{
+			"cache": {
+				Defaults:  RedisConfig{Addr: srv.Addr()},
+				Instances: map[string]RedisConfig{"main": {DB: 0}, "other": {DB: 1}},
+			},
+		},
+	}, WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("NewManagerFromConfig失败: %v", err)
+	}
+	defer manager.Close(ctx)
+
+	client, err := manager.MustGroup("cache").Get(ctx, "other")
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Errorf("Ping失败: %v", err)
+	}
+
+	var buf strings.Builder
+	_, _ = metrics.WriteTo(&buf)
+	if !strings.Contains(buf.String(), `group="cache",name="other"`) {
+		t.Errorf("选项应传给NewManager: %s", buf.String())
+	}
+}
@@ -0,0 +1,325 @@
+// Package lock 基于mgredis组中命名客户端的分布式锁
+//
+// 加锁使用 SET key token NX PX ttl，token为每次加锁随机生成的持有者标识，
+// 解锁和续期通过Lua脚本校验token，不会误删或延长其他持有者的锁。
+// 持有期间在后台自动续期，续期失败（锁已过期被他人获取或被删除）时通过Lost通知持有者。
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultTTL 锁的默认租约时长
+	DefaultTTL = 30 * time.Second
+
+	// DefaultRetryInterval Lock等待锁释放时的默认重试间隔
+	DefaultRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者持有
+	ErrNotAcquired = errors.New("mgredis/lock: lock not acquired")
+
+	// ErrNotHeld 锁已不再由当前持有者持有（已过期、被删除或已解锁）
+	ErrNotHeld = errors.New("mgredis/lock: lock not held")
+)
+
+// IsErrNotAcquired 判断是否为锁已被其他持有者持有错误
+func IsErrNotAcquired(err error) bool {
+	return errors.Is(err, ErrNotAcquired)
+}
+
+// IsErrNotHeld 判断是否为锁已不再持有错误
+func IsErrNotHeld(err error) bool {
+	return errors.Is(err, ErrNotHeld)
+}
+
+var (
+	// unlockScript token匹配时删除锁
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	// extendScript token匹配时重置过期时间
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Option 锁选项
+type Option func(*options)
+
+// options Locker和RedLocker共用的选项
+type options struct {
+	prefix        string
+	ttl           time.Duration
+	retryInterval time.Duration
+	autoExtend    bool
+	driftFactor   float64
+	nodeTimeout   time.Duration
+}
+
+// newOptions 应用选项并返回结果
+func newOptions(opts []Option) options {
+	o := options{
+		ttl:           DefaultTTL,
+		retryInterval: DefaultRetryInterval,
+		autoExtend:    true,
+		driftFactor:   DefaultDriftFactor,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTTL 设置锁的租约时长，自动续期时每隔TTL/3续期一次
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval 设置Lock等待锁释放时的重试间隔
+func WithRetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.retryInterval = d
+		}
+	}
+}
+
+// WithAutoExtend 设置持有期间是否自动续期，默认开启，仅对Locker有效
+// 关闭后锁在TTL后自动过期，可通过Lock.Extend手动续期
+func WithAutoExtend(enabled bool) Option {
+	return func(o *options) {
+		o.autoExtend = enabled
+	}
+}
+
+// WithKeyPrefix 设置锁键的前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// Locker 使用组中指定名称的客户端创建锁
+//
+// 每次操作都通过Group.Get获取客户端，客户端惰性创建，Reconfigure后自动使用新客户端。
+type Locker struct {
+	group mgredis.Group
+	name  string
+	opts  options
+}
+
+// New 创建Locker，name为组中已注册的客户端名称
+func New(group mgredis.Group, name string, opts ...Option) *Locker {
+	return &Locker{group: group, name: name, opts: newOptions(opts)}
+}
+
+// TryLock 尝试加锁，锁已被持有时立即返回ErrNotAcquired
+func (l *Locker) TryLock(ctx context.Context, key string) (*Lock, error) {
+	client, err := l.group.Get(ctx, l.name)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	key = l.opts.prefix + key
+	// TTL从服务端收到命令时开始计算，以发送前的时间为准，避免高估锁的剩余时间
+	start := time.Now()
+	ok, err := client.SetNX(ctx, key, token, l.opts.ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+
+	lk := &Lock{
+		locker:     l,
+		key:        key,
+		token:      token,
+		lost:       make(chan struct{}),
+		extendedAt: start,
+	}
+	if l.opts.autoExtend {
+		lk.quit = make(chan struct{})
+		lk.done = make(chan struct{})
+		go lk.keepAlive()
+	}
+	return lk, nil
+}
+
+// Lock 加锁，锁已被持有时每隔重试间隔重试，直到加锁成功或ctx结束
+func (l *Locker) Lock(ctx context.Context, key string) (*Lock, error) {
+	ticker := time.NewTicker(l.opts.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		lk, err := l.TryLock(ctx, key)
+		if !IsErrNotAcquired(err) {
+			return lk, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+
+	mu         sync.Mutex
+	extendedAt time.Time // 最近一次成功续期的命令发送时间
+	released   bool
+
+	lostOnce sync.Once
+	lost     chan struct{}
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// Key 返回锁的键（含前缀）
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者标识
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Lost 返回锁丢失时关闭的通道
+// 续期时发现锁已被他人持有或删除，或续期持续失败超过TTL时关闭；Unlock不会关闭该通道
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Extend 将锁的过期时间重置为ttl，锁已不再持有时返回ErrNotHeld
+func (lk *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	client, err := lk.locker.group.Get(ctx, lk.locker.name)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	n, err := extendScript.Run(ctx, client, []string{lk.key}, lk.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		lk.markLost()
+		return ErrNotHeld
+	}
+	lk.mu.Lock()
+	if start.After(lk.extendedAt) {
+		lk.extendedAt = start
+	}
+	lk.mu.Unlock()
+	return nil
+}
+
+// Unlock 停止自动续期并释放锁，锁已不再持有时返回ErrNotHeld
+func (lk *Lock) Unlock(ctx context.Context) error {
+	lk.mu.Lock()
+	if lk.released {
+		lk.mu.Unlock()
+		return ErrNotHeld
+	}
+	lk.released = true
+	lk.mu.Unlock()
+
+	if lk.quit != nil {
+		close(lk.quit)
+		<-lk.done
+	}
+
+	client, err := lk.locker.group.Get(ctx, lk.locker.name)
+	if err != nil {
+		return err
+	}
+	n, err := unlockScript.Run(ctx, client, []string{lk.key}, lk.token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// keepAlive 每隔TTL/3续期，锁丢失或解锁时退出
+func (lk *Lock) keepAlive() {
+	defer close(lk.done)
+
+	ttl := lk.locker.opts.ttl
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.quit:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		err := lk.Extend(ctx, ttl)
+		cancel()
+		switch {
+		case err == nil:
+		case IsErrNotHeld(err):
+			return
+		default:
+			// 网络等错误稍后重试，距最近一次成功续期超过TTL时锁已过期
+			lk.mu.Lock()
+			expired := time.Since(lk.extendedAt) >= ttl
+			lk.mu.Unlock()
+			if expired {
+				lk.markLost()
+				return
+			}
+		}
+	}
+}
+
+// markLost 关闭Lost通道
+func (lk *Lock) markLost() {
+	lk.lostOnce.Do(func() {
+		close(lk.lost)
+	})
+}
+
+// newToken 生成随机的持有者标识
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
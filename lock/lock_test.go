@@ -0,0 +1,223 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestGroup 启动miniredis并注册名为lock的客户端
+func newTestGroup(t *testing.T) (*miniredis.Miniredis, mgredis.Group) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	group := mgredis.New()
+	t.Cleanup(func() { group.Close(context.Background()) })
+	_, _ = group.Register(context.Background(), "lock", mgredis.RedisConfig{Addr: s.Addr()})
+	return s, group
+}
+
+// TestTryLock 测试加锁、互斥和解锁
+func TestTryLock(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	locker := New(group, "lock", WithKeyPrefix("lock:"), WithTTL(10*time.Second))
+
+	lk, err := locker.TryLock(ctx, "order:1")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if lk.Key() != "lock:order:1" || lk.Token() == "" {
+		t.Errorf("键或token不符: %q %q", lk.Key(), lk.Token())
+	}
+	if got, _ := s.Get("lock:order:1"); got != lk.Token() {
+		t.Errorf("锁的值应为token，实际为%q", got)
+	}
+	if ttl := s.TTL("lock:order:1"); ttl != 10*time.Second {
+		t.Errorf("预期TTL为10s，实际为%v", ttl)
+	}
+
+	if _, err := locker.TryLock(ctx, "order:1"); !IsErrNotAcquired(err) {
+		t.Errorf("锁已被持有时应返回ErrNotAcquired，实际为: %v", err)
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+	if s.Exists("lock:order:1") {
+		t.Error("解锁后键应被删除")
+	}
+	if err := lk.Unlock(ctx); !IsErrNotHeld(err) {
+		t.Errorf("重复解锁应返回ErrNotHeld，实际为: %v", err)
+	}
+}
+
+// TestUnlockOtherOwner 测试锁过期被他人获取后，原持有者不能解锁
+func TestUnlockOtherOwner(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	locker := New(group, "lock", WithTTL(time.Second), WithAutoExtend(false))
+
+	first, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	s.FastForward(2 * time.Second)
+
+	second, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("锁过期后应可重新加锁: %v", err)
+	}
+	if err := first.Unlock(ctx); !IsErrNotHeld(err) {
+		t.Errorf("原持有者解锁应返回ErrNotHeld，实际为: %v", err)
+	}
+	if err := first.Extend(ctx, time.Second); !IsErrNotHeld(err) {
+		t.Errorf("原持有者续期应返回ErrNotHeld，实际为: %v", err)
+	}
+	if got, _ := s.Get("k"); got != second.Token() {
+		t.Error("新持有者的锁不应被删除")
+	}
+}
+
+// TestLockWait 测试Lock等待锁释放及ctx取消
+func TestLockWait(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t)
+	locker := New(group, "lock", WithRetryInterval(10*time.Millisecond))
+
+	held, err := locker.Lock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Lock(timeoutCtx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ctx结束时应返回ctx错误，实际为: %v", err)
+	}
+
+	// 锁释放后等待者获取锁，同一时刻只有一个持有者
+	var (
+		wg      sync.WaitGroup
+		holders atomic.Int32
+		maxHeld atomic.Int32
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lk, err := locker.Lock(ctx, "k")
+			if err != nil {
+				t.Errorf("加锁失败: %v", err)
+				return
+			}
+			if n := holders.Add(1); n > maxHeld.Load() {
+				maxHeld.Store(n)
+			}
+			time.Sleep(5 * time.Millisecond)
+			holders.Add(-1)
+			_ = lk.Unlock(ctx)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	_ = held.Unlock(ctx)
+	wg.Wait()
+	if maxHeld.Load() != 1 {
+		t.Errorf("同一时刻应只有一个持有者，实际最多%d个", maxHeld.Load())
+	}
+}
+
+// TestAutoExtend 测试自动续期和锁丢失通知
+func TestAutoExtend(t *testing.T) {
+	ctx := context.Background()
+	s, group := newTestGroup(t)
+	locker := New(group, "lock", WithTTL(300*time.Millisecond))
+
+	lk, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+
+	// miniredis不会自动流逝时间，缩短TTL后检查是否被续期
+	s.SetTTL("k", time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for s.TTL("k") != 300*time.Millisecond && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ttl := s.TTL("k"); ttl != 300*time.Millisecond {
+		t.Fatalf("持有期间应自动续期，TTL为%v", ttl)
+	}
+
+	// 锁被他人覆盖后通知丢失
+	_ = s.Set("k", "other")
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("锁被覆盖后应通知丢失")
+	}
+	if err := lk.Unlock(ctx); !IsErrNotHeld(err) {
+		t.Errorf("锁丢失后解锁应返回ErrNotHeld，实际为: %v", err)
+	}
+	if got, _ := s.Get("k"); got != "other" {
+		t.Error("不应删除他人的锁")
+	}
+}
+
+// slowHook 在每条命令执行后等待delay，模拟回复延迟
+type slowHook struct {
+	delay time.Duration
+}
+
+func (h slowHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h slowHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		time.Sleep(h.delay)
+		return err
+	}
+}
+
+func (h slowHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestExtendedAtSendTime 测试续期时间以命令发送时间为准，回复延迟不会高估锁的剩余时间
+func TestExtendedAtSendTime(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t)
+	group.MustGet(ctx, "lock").AddHook(slowHook{delay: 200 * time.Millisecond})
+	locker := New(group, "lock", WithAutoExtend(false))
+
+	before := time.Now()
+	lk, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if d := lk.extendedAt.Sub(before); d > 100*time.Millisecond {
+		t.Errorf("加锁时间应取SETNX发送前的时间，实际晚了%v", d)
+	}
+
+	before = time.Now()
+	if err := lk.Extend(ctx, time.Second); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	if d := lk.extendedAt.Sub(before); d < 0 || d > 100*time.Millisecond {
+		t.Errorf("续期时间应取命令发送前的时间，实际相差%v", d)
+	}
+}
+
+// TestClientNotFound 测试客户端未注册时返回mgredis的错误
+func TestClientNotFound(t *testing.T) {
+	_, group := newTestGroup(t)
+	locker := New(group, "missing")
+	if _, err := locker.TryLock(context.Background(), "k"); !mgredis.IsErrClientNotFound(err) {
+		t.Errorf("预期ErrClientNotFound，实际为: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultDriftFactor Redlock时钟漂移系数，漂移按 TTL*系数+2ms 计算
+	DefaultDriftFactor = 0.01
+
+	// driftConstant 时钟漂移的固定部分，覆盖Redis过期精度
+	driftConstant = 2 * time.Millisecond
+)
+
+// WithDriftFactor 设置Redlock的时钟漂移系数，默认为0.01，仅对RedLocker有效
+func WithDriftFactor(factor float64) Option {
+	return func(o *options) {
+		if factor >= 0 {
+			o.driftFactor = factor
+		}
+	}
+}
+
+// WithNodeTimeout 设置Redlock在单个节点上执行命令的超时时间，默认为TTL/10，仅对RedLocker有效
+// 超时应远小于TTL，避免不可用的节点耗尽锁的有效时间；首次使用时创建客户端的耗时不受该超时限制
+func WithNodeTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.nodeTimeout = d
+		}
+	}
+}
+
+// RedLocker 在组中多个相互独立的实例上实现Redlock算法
+//
+// 加锁时在所有实例上执行 SET NX PX，多数（N/2+1）实例加锁成功且剩余有效时间为正时加锁成功，
+// 否则释放所有实例上的锁。实例通过Group.Get惰性创建，单个实例不可用时不影响在其余实例上加锁。
+// RedLocker不会自动续期，持有者应在Validity结束前完成操作或调用Extend。
+type RedLocker struct {
+	group mgredis.Group
+	names []string
+	opts  options
+}
+
+// NewRedLocker 创建RedLocker，names为组中已注册的相互独立的实例名称
+func NewRedLocker(group mgredis.Group, names []string, opts ...Option) *RedLocker {
+	o := newOptions(opts)
+	if o.nodeTimeout <= 0 {
+		o.nodeTimeout = o.ttl / 10
+	}
+	return &RedLocker{group: group, names: append([]string(nil), names...), opts: o}
+}
+
+// quorum 返回加锁成功所需的实例数
+func (r *RedLocker) quorum() int {
+	return len(r.names)/2 + 1
+}
+
+// TryLock 尝试在多数实例上加锁，多数实例有应答但加锁未达到多数或有效时间耗尽时返回ErrNotAcquired
+// 返回的错误同时包含各实例的错误（如mgredis.Error），可通过errors.Is/As判断；
+// 出错的实例过多、有应答的实例达不到多数时只返回各实例的错误，不包含ErrNotAcquired
+func (r *RedLocker) TryLock(ctx context.Context, key string) (*RedLock, error) {
+	if len(r.names) == 0 {
+		return nil, errors.New("mgredis/lock: redlock requires at least one instance")
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	key = r.opts.prefix + key
+
+	start := time.Now()
+	acquired, errs := r.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		return client.SetNX(ctx, key, token, r.opts.ttl).Result()
+	})
+
+	lk := &RedLock{locker: r, key: key, token: token}
+	if until := r.until(start); acquired >= r.quorum() && time.Until(until) > 0 {
+		lk.until = until
+		return lk, nil
+	}
+
+	// 未获取到锁时释放所有实例上可能已加的锁，忽略释放错误
+	_, _ = lk.release(context.WithoutCancel(ctx))
+	// 多数实例有应答时才是锁被占用或有效时间耗尽，否则只返回各实例的错误，Lock不再重试
+	if len(r.names)-len(errs) < r.quorum() {
+		return nil, errors.Join(errs...)
+	}
+	return nil, errors.Join(append([]error{ErrNotAcquired}, errs...)...)
+}
+
+// Lock 加锁，未获取到锁时在重试间隔内随机等待后重试，直到加锁成功或ctx结束
+// TryLock返回的错误不是ErrNotAcquired时（如多数实例未注册或认证失败）直接返回
+func (r *RedLocker) Lock(ctx context.Context, key string) (*RedLock, error) {
+	for {
+		lk, err := r.TryLock(ctx, key)
+		if err != nil && ctx.Err() != nil {
+			// ctx在加锁过程中结束时各实例均返回ctx错误，统一返回ctx.Err()
+			return nil, ctx.Err()
+		}
+		if !IsErrNotAcquired(err) {
+			return lk, err
+		}
+		// 随机等待，避免多个客户端同时重试时反复平分实例
+		timer := time.NewTimer(r.opts.retryInterval/2 + time.Duration(rand.Int63n(int64(r.opts.retryInterval))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// until 返回从start开始计算的锁有效期截止时间，已扣除时钟漂移
+// 加锁耗时由截止时间与当前时间的差自然扣除
+func (r *RedLocker) until(start time.Time) time.Time {
+	drift := time.Duration(float64(r.opts.ttl)*r.opts.driftFactor) + driftConstant
+	return start.Add(r.opts.ttl - drift)
+}
+
+// each 并发地在所有实例上执行fn，返回成功的实例数和失败实例的错误
+//
+// 实例的客户端通过Group.Get惰性创建，创建过程使用ctx；fn中的命令使用节点超时。
+func (r *RedLocker) each(ctx context.Context, fn func(ctx context.Context, client *redis.Client) (bool, error)) (int, []error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		n    int
+		errs []error
+	)
+	for _, name := range r.names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			ok, err := r.do(ctx, name, fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				errs = append(errs, err)
+			case ok:
+				n++
+			}
+		}(name)
+	}
+	wg.Wait()
+	return n, errs
+}
+
+// do 获取实例的客户端并在节点超时内执行fn
+func (r *RedLocker) do(ctx context.Context, name string, fn func(ctx context.Context, client *redis.Client) (bool, error)) (bool, error) {
+	client, err := r.group.Get(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	// 未启用ContextTimeoutEnabled的客户端会忽略上下文的截止时间，改用读写超时限制命令耗时
+	if !client.Options().ContextTimeoutEnabled {
+		client = client.WithTimeout(r.opts.nodeTimeout)
+	}
+	nodeCtx, cancel := context.WithTimeout(ctx, r.opts.nodeTimeout)
+	defer cancel()
+	ok, err := fn(nodeCtx, client)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return ok, nil
+}
+
+// RedLock 通过RedLocker获取的锁
+type RedLock struct {
+	locker *RedLocker
+	key    string
+	token  string
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// Key 返回锁的键（含前缀）
+func (lk *RedLock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者标识
+func (lk *RedLock) Token() string {
+	return lk.token
+}
+
+// Until 返回锁有效期的截止时间，已扣除加锁耗时和时钟漂移
+func (lk *RedLock) Until() time.Time {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+	return lk.until
+}
+
+// Validity 返回锁的剩余有效时间，小于等于0时锁已不再可靠
+func (lk *RedLock) Validity() time.Duration {
+	return time.Until(lk.Until())
+}
+
+// Extend 在所有实例上将锁的过期时间重置为TTL
+// 多数实例续期成功且剩余有效时间为正时更新有效期，否则返回ErrNotHeld
+func (lk *RedLock) Extend(ctx context.Context) error {
+	r := lk.locker
+	start := time.Now()
+	extended, errs := r.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		n, err := extendScript.Run(ctx, client, []string{lk.key}, lk.token, r.opts.ttl.Milliseconds()).Int64()
+		return n == 1, err
+	})
+
+	until := r.until(start)
+	if extended < r.quorum() || time.Until(until) <= 0 {
+		return errors.Join(append([]error{ErrNotHeld}, errs...)...)
+	}
+	lk.mu.Lock()
+	lk.until = until
+	lk.mu.Unlock()
+	return nil
+}
+
+// Unlock 释放所有实例上的锁
+// 没有任何实例释放成功时返回ErrNotHeld，部分实例出错时返回各实例的错误
+func (lk *RedLock) Unlock(ctx context.Context) error {
+	released, errs := lk.release(ctx)
+	lk.mu.Lock()
+	lk.until = time.Time{}
+	lk.mu.Unlock()
+
+	if released == 0 {
+		errs = append([]error{ErrNotHeld}, errs...)
+	}
+	return errors.Join(errs...)
+}
+
+// release 在所有实例上执行解锁脚本，返回释放成功的实例数
+func (lk *RedLock) release(ctx context.Context) (int, []error) {
+	r := lk.locker
+	return r.each(ctx, func(ctx context.Context, client *redis.Client) (bool, error) {
+		n, err := unlockScript.Run(ctx, client, []string{lk.key}, lk.token).Int64()
+		return n == 1, err
+	})
+}
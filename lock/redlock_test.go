@@ -0,0 +1,321 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedlockGroup 启动n个miniredis并注册为lock-1..lock-n
+func newRedlockGroup(t *testing.T, n int) ([]*miniredis.Miniredis, mgredis.Group, []string) {
+	t.Helper()
+	group := mgredis.New()
+	t.Cleanup(func() { group.Close(context.Background()) })
+
+	servers := make([]*miniredis.Miniredis, n)
+	names := make([]string, n)
+	for i := range servers {
+		servers[i] = miniredis.RunT(t)
+		names[i] = fmt.Sprintf("lock-%d", i+1)
+		_, _ = group.Register(context.Background(), names[i], mgredis.RedisConfig{Addr: servers[i].Addr()})
+	}
+	return servers, group, names
+}
+
+// newSlowProxy 在target前启动TCP代理，每个请求延迟delay后转发，hang为true时丢弃请求且不回复
+func newSlowProxy(t *testing.T, target string, delay *atomic.Int64, hang *atomic.Bool) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				server, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer server.Close()
+				go func() { _, _ = io.Copy(conn, server) }()
+
+				buf := make([]byte, 4096)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					if hang.Load() {
+						continue
+					}
+					time.Sleep(time.Duration(delay.Load()))
+					if _, err := server.Write(buf[:n]); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// newSlowRedlockGroup 启动3个miniredis，lock-1通过newSlowProxy访问
+func newSlowRedlockGroup(t *testing.T, delay *atomic.Int64, hang *atomic.Bool) (mgredis.Group, []string) {
+	t.Helper()
+	servers, group, names := newRedlockGroup(t, 3)
+	addr := newSlowProxy(t, servers[0].Addr(), delay, hang)
+	_, _ = group.Register(context.Background(), "slow", mgredis.RedisConfig{Addr: addr, ReadTimeout: 10 * time.Second})
+	// 预先创建客户端，使加锁耗时只包含命令的执行时间
+	for _, name := range append(names[1:], "slow") {
+		group.MustGet(context.Background(), name)
+	}
+	return group, append(names[1:], "slow")
+}
+
+// TestRedLocker 测试在所有实例上加锁、互斥和解锁
+func TestRedLocker(t *testing.T) {
+	ctx := context.Background()
+	servers, group, names := newRedlockGroup(t, 3)
+	locker := NewRedLocker(group, names, WithTTL(10*time.Second), WithKeyPrefix("lock:"))
+
+	lk, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if v := lk.Validity(); v <= 9*time.Second || v > 10*time.Second {
+		t.Errorf("有效时间应扣除耗时和时钟漂移，实际为%v", v)
+	}
+	for _, s := range servers {
+		if got, _ := s.Get("lock:k"); got != lk.Token() {
+			t.Errorf("每个实例上的锁的值应为token，实际为%q", got)
+		}
+	}
+
+	if _, err := locker.TryLock(ctx, "k"); !IsErrNotAcquired(err) {
+		t.Errorf("锁已被持有时应返回ErrNotAcquired，实际为: %v", err)
+	}
+
+	servers[0].SetTTL("lock:k", time.Second)
+	if err := lk.Extend(ctx); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	if ttl := servers[0].TTL("lock:k"); ttl != 10*time.Second {
+		t.Errorf("续期后TTL应为10s，实际为%v", ttl)
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+	for _, s := range servers {
+		if s.Exists("lock:k") {
+			t.Error("解锁后所有实例上的键应被删除")
+		}
+	}
+	if err := lk.Unlock(ctx); !IsErrNotHeld(err) {
+		t.Errorf("重复解锁应返回ErrNotHeld，实际为: %v", err)
+	}
+}
+
+// TestRedLockerQuorum 测试少数实例不可用时仍可加锁，未达到多数时释放已加的锁
+func TestRedLockerQuorum(t *testing.T) {
+	ctx := context.Background()
+	servers, group, names := newRedlockGroup(t, 5)
+	locker := NewRedLocker(group, names, WithNodeTimeout(100*time.Millisecond))
+
+	servers[0].Close()
+	servers[1].Close()
+	lk, err := locker.TryLock(ctx, "k")
+	if err != nil {
+		t.Fatalf("多数实例可用时应加锁成功: %v", err)
+	}
+	_ = lk.Unlock(ctx)
+
+	// 其他持有者占用两个实例，只能在一个实例上加锁，未达到多数
+	_ = servers[2].Set("k", "other")
+	_ = servers[3].Set("k", "other")
+	_, err = locker.TryLock(ctx, "k")
+	if !IsErrNotAcquired(err) {
+		t.Fatalf("未达到多数时应返回ErrNotAcquired，实际为: %v", err)
+	}
+	if !mgredis.IsErrPingFailed(err) {
+		t.Errorf("错误应包含不可用实例的mgredis错误: %v", err)
+	}
+	if servers[4].Exists("k") {
+		t.Error("加锁失败时应释放已加的锁")
+	}
+	if got, _ := servers[2].Get("k"); got != "other" {
+		t.Error("不应释放他人的锁")
+	}
+}
+
+// TestRedLockerAllErrors 测试所有实例都出错时返回实例的错误，Lock不重试
+func TestRedLockerAllErrors(t *testing.T) {
+	ctx := context.Background()
+	_, group, _ := newRedlockGroup(t, 0)
+	locker := NewRedLocker(group, []string{"missing-1", "missing-2", "missing-3"}, WithRetryInterval(10*time.Millisecond))
+
+	_, err := locker.TryLock(ctx, "k")
+	if IsErrNotAcquired(err) || !mgredis.IsErrClientNotFound(err) {
+		t.Fatalf("预期只返回ErrClientNotFound，实际为: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := locker.Lock(ctx, "k"); !mgredis.IsErrClientNotFound(err) {
+		t.Fatalf("预期Lock返回ErrClientNotFound，实际为: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("永久错误不应重试，实际耗时%v", elapsed)
+	}
+}
+
+// TestRedLockerValidity 测试有效时间耗尽时加锁失败
+func TestRedLockerValidity(t *testing.T) {
+	ctx := context.Background()
+	servers, group, names := newRedlockGroup(t, 3)
+
+	// 时钟漂移超过TTL，有效时间为负
+	locker := NewRedLocker(group, names, WithTTL(100*time.Millisecond), WithDriftFactor(1))
+	if _, err := locker.TryLock(ctx, "k"); !IsErrNotAcquired(err) {
+		t.Fatalf("有效时间耗尽时应返回ErrNotAcquired，实际为: %v", err)
+	}
+	for _, s := range servers {
+		if s.Exists("k") {
+			t.Error("加锁失败时应释放所有实例上的锁")
+		}
+	}
+}
+
+// TestRedLockerLockWait 测试Lock等待锁释放
+func TestRedLockerLockWait(t *testing.T) {
+	ctx := context.Background()
+	_, group, names := newRedlockGroup(t, 3)
+	locker := NewRedLocker(group, names, WithRetryInterval(10*time.Millisecond))
+
+	held, err := locker.Lock(ctx, "k")
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Lock(timeoutCtx, "k"); err != context.DeadlineExceeded {
+		t.Errorf("ctx结束时应返回ctx错误，实际为: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = held.Unlock(ctx)
+	}()
+	lk, err := locker.Lock(ctx, "k")
+	if err != nil {
+		t.Fatalf("锁释放后应加锁成功: %v", err)
+	}
+	_ = lk.Unlock(ctx)
+}
+
+// TestRedLockerSlowNode 测试有效时间只扣除一次加锁耗时
+func TestRedLockerSlowNode(t *testing.T) {
+	ctx := context.Background()
+	var delay atomic.Int64
+	var hang atomic.Bool
+	group, names := newSlowRedlockGroup(t, &delay, &hang)
+
+	ttl := time.Second
+	drift := time.Duration(float64(ttl)*DefaultDriftFactor) + driftConstant
+	locker := NewRedLocker(group, names, WithTTL(ttl), WithNodeTimeout(500*time.Millisecond))
+
+	delay.Store(int64(100 * time.Millisecond))
+	start := time.Now()
+	lk, err := locker.TryLock(ctx, "k")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if elapsed < 100*time.Millisecond {
+		t.Fatalf("慢节点应延长加锁耗时，实际为%v", elapsed)
+	}
+	if v := lk.Validity(); v < ttl-drift-elapsed-20*time.Millisecond || v > ttl-drift-100*time.Millisecond {
+		t.Errorf("有效时间应为TTL减去耗时（%v）和时钟漂移，实际为%v", elapsed, v)
+	}
+
+	start = time.Now()
+	if err := lk.Extend(ctx); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	elapsed = time.Since(start)
+	if v := lk.Validity(); v < ttl-drift-elapsed-20*time.Millisecond || v > ttl-drift-100*time.Millisecond {
+		t.Errorf("续期后有效时间应为TTL减去耗时（%v）和时钟漂移，实际为%v", elapsed, v)
+	}
+}
+
+// plainGroup 返回外部创建的、未启用ContextTimeoutEnabled的客户端
+type plainGroup struct {
+	mgredis.Group
+	clients map[string]*redis.Client
+}
+
+func (g plainGroup) Get(ctx context.Context, name string) (*redis.Client, error) {
+	return g.clients[name], nil
+}
+
+// TestRedLockerNodeTimeout 测试不回复的节点在节点超时后放弃，不等待ReadTimeout
+func TestRedLockerNodeTimeout(t *testing.T) {
+	ctx := context.Background()
+	var delay atomic.Int64
+	var hang atomic.Bool
+	group, names := newSlowRedlockGroup(t, &delay, &hang)
+
+	plain := plainGroup{Group: group, clients: make(map[string]*redis.Client)}
+	for _, name := range names {
+		cfg, _ := group.Config(ctx, name)
+		client := redis.NewClient(&redis.Options{Addr: cfg.Addr, ReadTimeout: 10 * time.Second})
+		t.Cleanup(func() { _ = client.Close() })
+		plain.clients[name] = client
+	}
+
+	for _, tt := range []struct {
+		name  string
+		group mgredis.Group
+	}{
+		{"group", group},
+		{"context timeout disabled", plain},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hang.Store(false)
+			locker := NewRedLocker(tt.group, names, WithTTL(10*time.Second), WithNodeTimeout(100*time.Millisecond))
+			if lk, err := locker.TryLock(ctx, tt.name); err == nil {
+				_ = lk.Unlock(ctx)
+			}
+
+			hang.Store(true)
+			start := time.Now()
+			lk, err := locker.TryLock(ctx, tt.name)
+			if err != nil {
+				t.Fatalf("多数实例可用时应加锁成功: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("不回复的节点应在节点超时后放弃，实际耗时%v", elapsed)
+			}
+			if v := lk.Validity(); v < 9*time.Second {
+				t.Errorf("有效时间不应被不回复的节点耗尽，实际为%v", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package mgredis
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WithLogger 设置日志，记录客户端的创建、Ping失败、关闭、注销和替换，
+// 以及执行时间超过RedisConfig.SlowThreshold的慢命令
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// log 记录实例相关的日志，未设置日志时不做任何事
+func (o *options) log(level slog.Level, msg string, key InstanceKey, cfg RedisConfig, args ...interface{}) {
+	if o.logger == nil {
+		return
+	}
+	attrs := []interface{}{"group", key.Group, "name", key.Name, "addr", instanceAddr(cfg)}
+	if cfg.Name != "" {
+		attrs = append(attrs, "config_name", cfg.Name)
+	}
+	o.logger.Log(context.Background(), level, msg, append(attrs, args...)...)
+}
+
+// logOpen 记录客户端创建结果
+func (o *options) logOpen(key InstanceKey, cfg RedisConfig, err error) {
+	switch {
+	case err == nil:
+		o.log(slog.LevelInfo, "redis client opened", key, cfg)
+	case IsErrPingFailed(err) || IsErrAuthFailed(err):
+		o.log(slog.LevelError, "redis ping failed", key, cfg, "error", err)
+	default:
+		o.log(slog.LevelError, "redis client open failed", key, cfg, "error", err)
+	}
+}
+
+// slowLogHook 记录慢命令的go-redis钩子
+type slowLogHook struct {
+	opts      *options
+	key       InstanceKey
+	cfg       RedisConfig
+	threshold time.Duration
+}
+
+func (h *slowLogHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *slowLogHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		if d := time.Since(start); d >= h.threshold {
+			h.opts.log(slog.LevelWarn, "slow redis command", h.key, h.cfg,
+				"command", sanitizeStatement(cmd.Args()), "duration", d)
+		}
+		return err
+	}
+}
+
+func (h *slowLogHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		if d := time.Since(start); d >= h.threshold {
+			h.opts.log(slog.LevelWarn, "slow redis pipeline", h.key, h.cfg,
+				"commands", len(cmds), "duration", d)
+		}
+		return err
+	}
+}
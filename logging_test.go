@@ -0,0 +1,144 @@
+package mgredis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer 并发安全的bytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records 解析JSON日志并返回消息为msg的记录
+func (b *syncBuffer) records(t *testing.T, msg string) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var list []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("解析日志失败: %v: %s", err, line)
+		}
+		if rec["msg"] == msg {
+			list = append(list, rec)
+		}
+	}
+	return list
+}
+
+// TestLogger 测试生命周期日志
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	a := newFakeRedisNamed(t, "a")
+	b := newFakeRedisNamed(t, "b")
+
+	var buf syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	manager := NewManager(WithLogger(logger), WithGracePeriod(0))
+	manager.AddGroup("cache")
+	group := manager.MustGroup("cache")
+
+	_, _ = group.Register(ctx, "main", RedisConfig{Name: "主缓存", Addr: a.Addr()})
+	_, _ = group.Register(ctx, "down", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: -1})
+	_, _ = group.Register(ctx, "broken", RedisConfig{})
+	_, _ = group.Register(ctx, "other", RedisConfig{Addr: a.Addr()})
+
+	group.MustGet(ctx, "main")
+	group.MustGet(ctx, "other")
+	_, _ = group.Get(ctx, "down")
+	_, _ = group.Get(ctx, "broken")
+
+	opened := buf.records(t, "redis client opened")
+	if len(opened) != 2 || opened[0]["group"] != "cache" || opened[0]["name"] != "main" ||
+		opened[0]["addr"] != a.Addr() || opened[0]["config_name"] != "主缓存" || opened[0]["level"] != "INFO" {
+		t.Errorf("创建日志错误: %v", opened)
+	}
+	if rec := buf.records(t, "redis ping failed"); len(rec) != 1 || rec[0]["name"] != "down" || rec[0]["level"] != "ERROR" || rec[0]["error"] == nil {
+		t.Errorf("Ping失败日志错误: %v", rec)
+	}
+	if rec := buf.records(t, "redis client open failed"); len(rec) != 1 || rec[0]["name"] != "broken" {
+		t.Errorf("创建失败日志错误: %v", rec)
+	}
+
+	if err := group.Reconfigure(ctx, "main", RedisConfig{Addr: b.Addr()}); err != nil {
+		t.Fatalf("Reconfigure失败: %v", err)
+	}
+	if rec := buf.records(t, "redis client reconfigured"); len(rec) != 1 || rec[0]["addr"] != b.Addr() || rec[0]["old_addr"] != a.Addr() {
+		t.Errorf("替换日志错误: %v", rec)
+	}
+	if rec := buf.records(t, "redis client closed"); len(rec) != 1 || rec[0]["addr"] != a.Addr() {
+		t.Errorf("替换后应记录旧客户端关闭: %v", rec)
+	}
+
+	if err := group.Unregister(ctx, "main"); err != nil {
+		t.Fatalf("注销失败: %v", err)
+	}
+	if rec := buf.records(t, "redis client unregistered"); len(rec) != 1 || rec[0]["name"] != "main" {
+		t.Errorf("注销日志错误: %v", rec)
+	}
+
+	manager.Close(ctx)
+	closed := buf.records(t, "redis client closed")
+	if len(closed) != 3 || closed[1]["name"] != "main" || closed[2]["name"] != "other" {
+		t.Errorf("关闭日志错误: %v", closed)
+	}
+}
+
+// TestSlowLog 测试慢命令日志
+func TestSlowLog(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		switch strings.ToUpper(args[0]) {
+		case "SLOW":
+			time.Sleep(30 * time.Millisecond)
+			return respSimple("OK"), true
+		case "FAST":
+			return respSimple("OK"), true
+		}
+		return nil, false
+	})
+
+	var buf syncBuffer
+	group := New(WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	defer group.Close(ctx)
+	_, _ = group.Register(ctx, "slow", RedisConfig{Addr: s.Addr(), SlowThreshold: 20 * time.Millisecond})
+	_, _ = group.Register(ctx, "off", RedisConfig{Addr: s.Addr()})
+
+	client := group.MustGet(ctx, "slow")
+	client.Do(ctx, "fast", "k")
+	client.Do(ctx, "slow", "k", "secret")
+	pipe := client.Pipeline()
+	pipe.Do(ctx, "slow", "k")
+	_, _ = pipe.Exec(ctx)
+	group.MustGet(ctx, "off").Do(ctx, "slow", "k")
+
+	rec := buf.records(t, "slow redis command")
+	if len(rec) != 1 || rec[0]["command"] != "slow k ?" || rec[0]["name"] != "slow" || rec[0]["level"] != "WARN" {
+		t.Fatalf("慢命令日志错误: %v", rec)
+	}
+	if d, _ := rec[0]["duration"].(float64); time.Duration(d) < 20*time.Millisecond {
+		t.Errorf("耗时错误: %v", rec[0]["duration"])
+	}
+	if rec := buf.records(t, "slow redis pipeline"); len(rec) != 1 || rec[0]["commands"] != float64(1) {
+		t.Errorf("慢流水线日志错误: %v", rec)
+	}
+}
@@ -0,0 +1,323 @@
+package mgredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultMetricsBuckets 命令耗时直方图的默认桶（秒）
+var DefaultMetricsBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// pipelineCommand 流水线在指标中的命令名
+const pipelineCommand = "pipeline"
+
+// MetricsOption 指标收集器选项
+type MetricsOption func(*Metrics)
+
+// WithMetricsBuckets 设置命令耗时直方图的桶（秒，升序）
+func WithMetricsBuckets(buckets ...float64) MetricsOption {
+	return func(m *Metrics) {
+		if len(buckets) > 0 {
+			m.buckets = append([]float64(nil), buckets...)
+			sort.Float64s(m.buckets)
+		}
+	}
+}
+
+// WithMetrics 使用指标收集器，管理器创建的每个客户端都会自动注册到收集器
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// Metrics 以Prometheus文本格式输出连接池和命令指标，不依赖Prometheus客户端库
+//
+// 连接池指标和熔断器状态在输出时从管理器中所有已创建的客户端读取，
+// 命令耗时和错误次数由创建客户端时安装的钩子记录，标签为group、name和command，
+// 读写分离客户端的从节点命令额外带有replica标签（从节点地址）。
+type Metrics struct {
+	buckets []float64
+
+	mu       sync.Mutex
+	sources  []func() []*group
+	commands map[commandKey]*commandStats
+}
+
+// commandKey 命令指标的标签
+type commandKey struct {
+	InstanceKey
+	replica string
+	command string
+}
+
+// commandStats 单个命令的耗时直方图和错误次数
+type commandStats struct {
+	counts []uint64 // 每个桶的计数（非累计）
+	count  uint64
+	sum    float64
+	errors uint64
+}
+
+// NewMetrics 创建指标收集器，通过WithMetrics传给New或NewManager
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		buckets:  DefaultMetricsBuckets,
+		commands: make(map[commandKey]*commandStats),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// addSource 添加需要输出连接池指标的组
+func (m *Metrics) addSource(groups func() []*group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sources = append(m.sources, groups)
+}
+
+// observe 记录一次命令执行
+func (m *Metrics) observe(ck commandKey, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.commands[ck]
+	if !ok {
+		st = &commandStats{counts: make([]uint64, len(m.buckets))}
+		m.commands[ck] = st
+	}
+
+	seconds := d.Seconds()
+	st.count++
+	st.sum += seconds
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		st.counts[i]++
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		st.errors++
+	}
+}
+
+// hook 返回记录命令指标的钩子，replica为从节点地址，主节点客户端为空
+func (m *Metrics) hook(key InstanceKey, replica string) redis.Hook {
+	return &metricsHook{m: m, key: key, replica: replica}
+}
+
+// ServeHTTP 以Prometheus文本格式输出指标，可直接挂载到/metrics
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo 以Prometheus文本格式写出所有指标
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	m.writePoolStats(cw)
+	m.writeCommandStats(cw)
+	if cw.err == nil {
+		cw.err = bw.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// poolMetric 连接池指标定义
+type poolMetric struct {
+	name, typ, help string
+	value           func(*redis.PoolStats) uint32
+}
+
+var poolMetrics = []poolMetric{
+	{"mgredis_pool_hits_total", "counter", "Number of times a free connection was found in the pool.",
+		func(s *redis.PoolStats) uint32 { return s.Hits }},
+	{"mgredis_pool_misses_total", "counter", "Number of times a free connection was not found in the pool.",
+		func(s *redis.PoolStats) uint32 { return s.Misses }},
+	{"mgredis_pool_timeouts_total", "counter", "Number of times a wait for a connection timed out.",
+		func(s *redis.PoolStats) uint32 { return s.Timeouts }},
+	{"mgredis_pool_total_conns", "gauge", "Number of total connections in the pool.",
+		func(s *redis.PoolStats) uint32 { return s.TotalConns }},
+	{"mgredis_pool_idle_conns", "gauge", "Number of idle connections in the pool.",
+		func(s *redis.PoolStats) uint32 { return s.IdleConns }},
+	{"mgredis_pool_stale_conns_total", "counter", "Number of stale connections removed from the pool.",
+		func(s *redis.PoolStats) uint32 { return s.StaleConns }},
+}
+
+// writePoolStats 输出所有已创建客户端的连接池指标
+func (m *Metrics) writePoolStats(w *countingWriter) {
+	m.mu.Lock()
+	sources := append([]func() []*group(nil), m.sources...)
+	m.mu.Unlock()
+
+	type pool struct {
+		key     InstanceKey
+		stats   *redis.PoolStats
+		breaker *circuitBreaker
+	}
+	var pools []pool
+	for _, groups := range sources {
+		for _, g := range groups() {
+			g.state.opened.Range(func(name, client interface{}) bool {
+				p := pool{
+					key:   InstanceKey{Group: g.name, Name: name.(string)},
+					stats: client.(*redis.Client).PoolStats(),
+				}
+				if b, ok := g.state.breakers.Load(client); ok {
+					p.breaker = b.(*circuitBreaker)
+				}
+				pools = append(pools, p)
+				return true
+			})
+		}
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		return instanceKeyLess(pools[i].key, pools[j].key)
+	})
+
+	for _, pm := range poolMetrics {
+		w.header(pm.name, pm.typ, pm.help)
+		for _, p := range pools {
+			w.printf("%s{%s} %d\n", pm.name, labels(p.key, ""), pm.value(p.stats))
+		}
+	}
+
+	// 仅输出启用熔断的客户端
+	const circuitState = "mgredis_circuit_state"
+	w.header(circuitState, "gauge", "Circuit breaker state: 0 closed, 1 half-open, 2 open.")
+	for _, p := range pools {
+		if p.breaker != nil {
+			w.printf("%s{%s} %d\n", circuitState, labels(p.key, ""), p.breaker.State())
+		}
+	}
+}
+
+// writeCommandStats 输出命令耗时直方图和错误次数
+func (m *Metrics) writeCommandStats(w *countingWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]commandKey, 0, len(m.commands))
+	for k := range m.commands {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].InstanceKey != keys[j].InstanceKey {
+			return instanceKeyLess(keys[i].InstanceKey, keys[j].InstanceKey)
+		}
+		if keys[i].replica != keys[j].replica {
+			return keys[i].replica < keys[j].replica
+		}
+		return keys[i].command < keys[j].command
+	})
+
+	const duration = "mgredis_command_duration_seconds"
+	w.header(duration, "histogram", "Redis command latency in seconds.")
+	for _, k := range keys {
+		st := m.commands[k]
+		l := commandLabels(k)
+		var cumulative uint64
+		for i, le := range m.buckets {
+			cumulative += st.counts[i]
+			w.printf("%s_bucket{%s,le=\"%s\"} %d\n", duration, l, formatFloat(le), cumulative)
+		}
+		w.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", duration, l, st.count)
+		w.printf("%s_sum{%s} %s\n", duration, l, formatFloat(st.sum))
+		w.printf("%s_count{%s} %d\n", duration, l, st.count)
+	}
+
+	const errorsTotal = "mgredis_command_errors_total"
+	w.header(errorsTotal, "counter", "Number of Redis commands that returned an error (excluding nil replies).")
+	for _, k := range keys {
+		w.printf("%s{%s} %d\n", errorsTotal, commandLabels(k), m.commands[k].errors)
+	}
+}
+
+// labels 返回group、name及可选的command标签
+func labels(key InstanceKey, command string) string {
+	l := fmt.Sprintf(`group="%s",name="%s"`, escapeLabel(key.Group), escapeLabel(key.Name))
+	if command != "" {
+		l += fmt.Sprintf(`,command="%s"`, escapeLabel(command))
+	}
+	return l
+}
+
+// commandLabels 返回命令指标的标签，从节点命令在name之后带有replica标签
+func commandLabels(k commandKey) string {
+	l := labels(k.InstanceKey, "")
+	if k.replica != "" {
+		l += fmt.Sprintf(`,replica="%s"`, escapeLabel(k.replica))
+	}
+	return l + fmt.Sprintf(`,command="%s"`, escapeLabel(k.command))
+}
+
+// labelEscaper 按Prometheus文本格式转义标签值
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter 记录写入的字节数和第一个错误
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *countingWriter) header(name, typ, help string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// metricsHook 记录命令耗时和错误次数的go-redis钩子
+type metricsHook struct {
+	m       *Metrics
+	key     InstanceKey
+	replica string
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.m.observe(commandKey{InstanceKey: h.key, replica: h.replica, command: cmd.Name()}, time.Since(start), err)
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.m.observe(commandKey{InstanceKey: h.key, replica: h.replica, command: pipelineCommand}, time.Since(start), err)
+		return err
+	}
+}
@@ -0,0 +1,82 @@
+package mgredis
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetrics 测试连接池和命令指标输出
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if strings.EqualFold(args[0], "GET") {
+			if args[1] == "missing" {
+				return nil, true
+			}
+			return "v", true
+		}
+		return nil, false
+	})
+
+	metrics := NewMetrics(WithMetricsBuckets(10, 0.5))
+	manager := NewManager(WithMetrics(metrics))
+	defer manager.Close(ctx)
+	manager.AddGroup("cache")
+	_, _ = manager.MustGroup("cache").Register(ctx, `ma"in`, RedisConfig{Addr: s.Addr()})
+	_, _ = manager.MustGroup("cache").Register(ctx, "lazy", RedisConfig{Addr: s.Addr()})
+
+	client := manager.MustGroup("cache").MustGet(ctx, `ma"in`)
+	client.Get(ctx, "k")
+	client.Get(ctx, "k")
+	client.Get(ctx, "missing")
+	client.Do(ctx, "bogus")
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "k")
+	pipe.Get(ctx, "k")
+	_, _ = pipe.Exec(ctx)
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type错误: %s", ct)
+	}
+	out := rec.Body.String()
+
+	l := `group="cache",name="ma\"in"`
+	for _, want := range []string{
+		"# TYPE mgredis_pool_hits_total counter",
+		"# TYPE mgredis_pool_idle_conns gauge",
+		"mgredis_pool_total_conns{" + l + "} ",
+		"mgredis_pool_stale_conns_total{" + l + "} 0",
+		"# TYPE mgredis_command_duration_seconds histogram",
+		"mgredis_command_duration_seconds_bucket{" + l + `,command="get",le="0.5"} 3`,
+		"mgredis_command_duration_seconds_bucket{" + l + `,command="get",le="10"} 3`,
+		"mgredis_command_duration_seconds_bucket{" + l + `,command="get",le="+Inf"} 3`,
+		"mgredis_command_duration_seconds_count{" + l + `,command="get"} 3`,
+		"mgredis_command_duration_seconds_count{" + l + `,command="pipeline"} 1`,
+		"mgredis_command_errors_total{" + l + `,command="get"} 0`,
+		"mgredis_command_errors_total{" + l + `,command="bogus"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出缺少%q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `name="lazy"`) {
+		t.Error("未创建的客户端不应输出指标")
+	}
+
+	// 替换后的客户端同样记录指标
+	if err := manager.MustGroup("cache").Reconfigure(ctx, "lazy", RedisConfig{Addr: s.Addr(), DB: 1}); err != nil {
+		t.Fatalf("Reconfigure失败: %v", err)
+	}
+	manager.MustGroup("cache").MustGet(ctx, "lazy").Get(ctx, "k")
+	var sb strings.Builder
+	if _, err := metrics.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo失败: %v", err)
+	}
+	if !strings.Contains(sb.String(), `mgredis_command_duration_seconds_count{group="cache",name="lazy",command="get"} 1`) {
+		t.Errorf("替换后的客户端应记录指标\n%s", sb.String())
+	}
+}
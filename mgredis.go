@@ -2,7 +2,10 @@ package mgredis
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/qq1060656096/bizutil/registry"
@@ -11,14 +14,136 @@ import (
 
 // opener 创建Redis客户端连接
 func opener(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
+	client, err := buildClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用超时上下文进行Ping测试
+	ctx2, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx2).Err(); err != nil {
+		_ = client.Close()
+		kind := ErrPingFailed
+		if isAuthError(err) {
+			kind = ErrAuthFailed
+		}
+		return nil, &Error{Op: OpPing, Addr: instanceAddr(cfg), Kind: kind, Err: err}
+	}
+
+	return client, nil
+}
+
+// buildClient 检查配置并创建Redis客户端，不进行Ping测试
+func buildClient(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
 	// 检查并设置默认值
 	if err := cfg.CheckAndSetDefaults(); err != nil {
-		return nil, err
+		return nil, &Error{Op: OpOpen, Addr: instanceAddr(cfg), Err: err}
+	}
+
+	// 创建TLS配置
+	tlsConfig, err := cfg.TLS.Build(cfg.tlsServerName())
+	if err != nil {
+		return nil, &Error{Op: OpOpen, Addr: instanceAddr(cfg), Err: err}
+	}
+
+	// 创建客户端
+	return newClient(cfg, tlsConfig), nil
+}
+
+// authErrorPrefixes 认证失败时Redis返回的错误前缀
+var authErrorPrefixes = []string{
+	"WRONGPASS",
+	"NOAUTH",
+	"NOPERM",
+	"ERR invalid password",
+	"ERR invalid username-password pair",
+	"ERR AUTH",
+	"ERR Client sent AUTH",
+}
+
+// credentialsError 凭据提供者返回的错误，归类为认证失败
+type credentialsError struct {
+	err error
+}
+
+func (e *credentialsError) Error() string {
+	return "credentials provider: " + e.err.Error()
+}
+
+func (e *credentialsError) Unwrap() error {
+	return e.err
+}
+
+// credentialsProvider 包装凭据提供者，将其返回的错误标记为credentialsError
+func credentialsProvider(provider CredentialsProvider) func(ctx context.Context) (string, string, error) {
+	if provider == nil {
+		return nil
+	}
+	return func(ctx context.Context) (string, string, error) {
+		username, password, err := provider(ctx)
+		if err != nil {
+			// go-redis在初始化连接失败时会解开一层包装，外层包装被解开后仍保留credentialsError
+			return "", "", fmt.Errorf("%w", &credentialsError{err: err})
+		}
+		return username, password, nil
+	}
+}
+
+// isAuthError 判断是否为Redis返回的认证失败错误或凭据提供者返回的错误
+func isAuthError(err error) bool {
+	var credErr *credentialsError
+	if errors.As(err, &credErr) {
+		return true
 	}
 
-	// 创建Redis客户端选项
-	opts := &redis.Options{
+	var redisErr redis.Error
+	if !errors.As(err, &redisErr) {
+		return false
+	}
+	msg := redisErr.Error()
+	for _, prefix := range authErrorPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newClient 根据配置创建Redis客户端，Sentinel模式下创建支持故障转移的客户端
+func newClient(cfg RedisConfig, tlsConfig *tls.Config) *redis.Client {
+	if cfg.IsSentinel() {
+		client := redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.SentinelAddrs,
+			SentinelPassword: cfg.SentinelPassword,
+			ReplicaOnly:      cfg.ReplicaOnly,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConns,
+			DialTimeout:      cfg.DialTimeout,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+			MaxRetries:       cfg.MaxRetries,
+			PoolTimeout:      cfg.PoolTimeout,
+			ConnMaxIdleTime:  cfg.IdleTimeout,
+			TLSConfig:        tlsConfig,
+
+			ContextTimeoutEnabled: true,
+		})
+		// FailoverOptions不支持凭据提供者，这里设置到主节点客户端的选项上，
+		// 每次建立主节点连接时都会调用，Sentinel节点的连接不受影响
+		client.Options().CredentialsProviderContext = credentialsProvider(cfg.CredentialsProvider)
+		return client
+	}
+
+	return redis.NewClient(&redis.Options{
+		Network:         cfg.Network,
 		Addr:            cfg.Addr,
+		Username:        cfg.Username,
 		Password:        cfg.Password,
 		DB:              cfg.DB,
 		PoolSize:        cfg.PoolSize,
@@ -29,21 +154,12 @@ func opener(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
 		MaxRetries:      cfg.MaxRetries,
 		PoolTimeout:     cfg.PoolTimeout,
 		ConnMaxIdleTime: cfg.IdleTimeout,
-	}
-
-	// 创建客户端
-	client := redis.NewClient(opts)
-
-	// 使用超时上下文进行Ping测试
-	ctx2, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx2).Err(); err != nil {
-		_ = client.Close()
-		return nil, fmt.Errorf("%w: %v", ErrPingFailed, err)
-	}
+		TLSConfig:       tlsConfig,
 
-	return client, nil
+		// 使上下文的截止时间生效，健康检查等操作的超时不受ReadTimeout限制
+		ContextTimeoutEnabled:      true,
+		CredentialsProviderContext: credentialsProvider(cfg.CredentialsProvider),
+	})
 }
 
 // closer 关闭Redis客户端连接
@@ -55,21 +171,73 @@ func closer(ctx context.Context, client *redis.Client) error {
 }
 
 // Group 是单一组管理（key => redis client）
-type Group registry.Group[RedisConfig, *redis.Client]
+type Group interface {
+	registry.Group[RedisConfig, *redis.Client]
+
+	// Reconfigure 使用新配置原子地替换已注册的客户端
+	// 总是立即用新配置创建客户端并Ping（即使原客户端尚未创建），成功后才会替换，旧客户端在排空等待时间后关闭
+	Reconfigure(ctx context.Context, name string, cfg RedisConfig) error
+
+	// WarmUp 并发创建组内所有已注册的客户端，返回所有创建失败的实例
+	WarmUp(ctx context.Context) error
+
+	// Status 返回组内所有已创建客户端的健康状态（需通过WithHealthCheck启用后台检查）
+	Status() []ClientStatus
+
+	// GetReadWrite 获取读写分离客户端，只读命令路由到RedisConfig.Replicas中的从节点
+	GetReadWrite(ctx context.Context, name string) (*ReadWriteClient, error)
+}
 
 // Manager 是多组管理
-type Manager registry.Manager[RedisConfig, *redis.Client]
+type Manager interface {
+	// Group 根据名称获取组，组不存在时返回错误
+	Group(name string) (Group, error)
+
+	// MustGroup 根据名称获取组，组不存在时触发panic
+	MustGroup(name string) Group
+
+	// AddGroup 添加组，返回值表示组是否已经存在
+	AddGroup(name string) bool
+
+	// ListGroupNames 返回所有组名
+	ListGroupNames() []string
+
+	// WarmUp 并发创建所有组内已注册的客户端，返回所有创建失败的实例
+	WarmUp(ctx context.Context) error
+
+	// Status 返回所有组内已创建客户端的健康状态（需通过WithHealthCheck启用后台检查）
+	Status() []ClientStatus
+
+	// Close 关闭所有组的所有客户端
+	Close(ctx context.Context) []error
+}
 
 // New 创建单组Redis客户端管理器
 // 用于管理多个命名的Redis客户端实例
 // 支持惰性初始化（首次Get时创建）和安全关闭所有资源
-func New() Group {
-	return registry.New[RedisConfig, *redis.Client](opener, closer)
+func New(opts ...Option) Group {
+	rg := registry.New[RedisConfig, *redis.Client](groupOpener, groupCloser)
+	g := newGroup(rg, defaultGroupName, newOptions(opts), &groupState{})
+	groups := func() []*group { return []*group{g} }
+	g.checker = startHealthChecker(g.opts.health, groups)
+	if g.opts.metrics != nil {
+		g.opts.metrics.addSource(groups)
+	}
+	return g
 }
 
 // NewManager 创建多组Redis客户端管理器
 // 用于管理多个组，每个组可以包含多个命名的Redis客户端实例
 // 适用于需要按业务场景分组管理Redis连接的复杂场景
-func NewManager() Manager {
-	return registry.NewManager[RedisConfig, *redis.Client](opener, closer)
+func NewManager(opts ...Option) Manager {
+	m := &manager{
+		Manager: registry.NewManager[RedisConfig, *redis.Client](groupOpener, groupCloser),
+		opts:    newOptions(opts),
+		states:  make(map[string]*groupState),
+	}
+	m.checker = startHealthChecker(m.opts.health, m.groups)
+	if m.opts.metrics != nil {
+		m.opts.metrics.addSource(m.groups)
+	}
+	return m
 }
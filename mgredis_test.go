@@ -2,6 +2,7 @@ package mgredis
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 )
@@ -136,6 +137,135 @@ func TestOpener(t *testing.T) {
 	})
 }
 
+// newFakeSentinel 启动一个只应答主从地址查询的fakeSentinel
+func newFakeSentinel(t *testing.T, masterName string, master, replica *fakeRedis) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if len(args) < 3 || !strings.EqualFold(args[0], "SENTINEL") {
+			return nil, false
+		}
+		if args[2] != masterName {
+			return nil, true
+		}
+
+		switch strings.ToLower(args[1]) {
+		case "get-master-addr-by-name":
+			return []string{master.Host(), master.Port()}, true
+		case "replicas", "slaves":
+			if replica == nil {
+				return []interface{}{}, true
+			}
+			return []interface{}{
+				[]string{"ip", replica.Host(), "port", replica.Port(), "flags", "slave"},
+			}, true
+		case "sentinels":
+			return []interface{}{}, true
+		}
+		return nil, false
+	})
+}
+
+// TestOpenerSentinel 测试Sentinel模式下的opener
+func TestOpenerSentinel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("缺少Sentinel地址", func(t *testing.T) {
+		cfg := RedisConfig{MasterName: "mymaster"}
+
+		_, err := opener(ctx, cfg)
+		if !IsErrNoSentinelAddrs(err) {
+			t.Errorf("预期ErrNoSentinelAddrs错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("Sentinel模式不要求Addr", func(t *testing.T) {
+		cfg := RedisConfig{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{"127.0.0.1:26379"},
+		}
+
+		if err := cfg.CheckAndSetDefaults(); err != nil {
+			t.Fatalf("CheckAndSetDefaults失败: %v", err)
+		}
+	})
+
+	t.Run("通过Sentinel连接主节点", func(t *testing.T) {
+		master := newFakeRedis(t, func(args []string) (interface{}, bool) {
+			if strings.EqualFold(args[0], "GET") {
+				return "master", true
+			}
+			return nil, false
+		})
+		sentinel := newFakeSentinel(t, "mymaster", master, nil)
+
+		group := New()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "cache", RedisConfig{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{sentinel.Addr()},
+			DialTimeout:   time.Second,
+		})
+
+		client, err := group.Get(ctx, "cache")
+		if err != nil {
+			t.Fatalf("获取客户端失败: %v", err)
+		}
+
+		val, err := client.Get(ctx, "role").Result()
+		if err != nil {
+			t.Fatalf("GET失败: %v", err)
+		}
+		if val != "master" {
+			t.Errorf("预期连接到主节点，实际为%s", val)
+		}
+	})
+
+	t.Run("只读从节点路由", func(t *testing.T) {
+		handler := func(role string) fakeHandler {
+			return func(args []string) (interface{}, bool) {
+				if strings.EqualFold(args[0], "GET") {
+					return role, true
+				}
+				return nil, false
+			}
+		}
+		master := newFakeRedis(t, handler("master"))
+		replica := newFakeRedis(t, handler("replica"))
+		sentinel := newFakeSentinel(t, "mymaster", master, replica)
+
+		client, err := opener(ctx, RedisConfig{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{sentinel.Addr()},
+			ReplicaOnly:   true,
+			DialTimeout:   time.Second,
+		})
+		if err != nil {
+			t.Fatalf("创建客户端失败: %v", err)
+		}
+		defer client.Close()
+
+		val, err := client.Get(ctx, "role").Result()
+		if err != nil {
+			t.Fatalf("GET失败: %v", err)
+		}
+		if val != "replica" {
+			t.Errorf("预期连接到从节点，实际为%s", val)
+		}
+	})
+
+	t.Run("Sentinel不可达", func(t *testing.T) {
+		_, err := opener(ctx, RedisConfig{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{"127.0.0.1:1"},
+			DialTimeout:   200 * time.Millisecond,
+			MaxRetries:    1,
+		})
+		if !IsErrPingFailed(err) {
+			t.Errorf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+	})
+}
+
 // TestCloser 测试closer函数
 func TestCloser(t *testing.T) {
 	ctx := context.Background()
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc 从请求中提取限流键，返回空字符串时该请求不限流
+type KeyFunc func(r *http.Request) string
+
+// MiddlewareOption 中间件选项
+type MiddlewareOption func(*middleware)
+
+// WithDeniedHandler 设置请求被拒绝时的处理器，默认返回429
+// 调用处理器前已设置X-RateLimit-*和Retry-After响应头
+func WithDeniedHandler(h http.Handler) MiddlewareOption {
+	return func(m *middleware) {
+		if h != nil {
+			m.denied = h
+		}
+	}
+}
+
+// WithErrorHandler 设置限流出错（如Redis不可用）时的处理函数
+// 默认放行请求，fn中未写入响应时也不会继续调用下一个处理器
+func WithErrorHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) MiddlewareOption {
+	return func(m *middleware) {
+		m.onError = fn
+	}
+}
+
+// middleware HTTP限流中间件
+type middleware struct {
+	limiter *Limiter
+	limit   Limit
+	key     KeyFunc
+	denied  http.Handler
+	onError func(w http.ResponseWriter, r *http.Request, err error)
+	next    http.Handler
+}
+
+// Middleware 返回按key限流的HTTP中间件
+//
+// 受限流的响应带有X-RateLimit-Limit、X-RateLimit-Remaining和X-RateLimit-Reset（秒）响应头，
+// 被拒绝时带有Retry-After（秒）并默认返回429 Too Many Requests。
+func Middleware(limiter *Limiter, limit Limit, key KeyFunc, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		m := &middleware{
+			limiter: limiter,
+			limit:   limit,
+			key:     key,
+			denied:  http.HandlerFunc(tooManyRequests),
+			next:    next,
+		}
+		for _, opt := range opts {
+			opt(m)
+		}
+		return m
+	}
+}
+
+// ServeHTTP 实现http.Handler
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := m.key(r)
+	if key == "" {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	res, err := m.limiter.Allow(r.Context(), key, m.limit)
+	if err != nil {
+		if m.onError != nil {
+			m.onError(w, r, err)
+			return
+		}
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit.Rate))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("X-RateLimit-Reset", seconds(res.ResetAfter))
+	if !res.Allowed {
+		h.Set("Retry-After", seconds(res.RetryAfter))
+		m.denied.ServeHTTP(w, r)
+		return
+	}
+	m.next.ServeHTTP(w, r)
+}
+
+// tooManyRequests 默认的拒绝处理器
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// seconds 将时长向上取整为秒
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestMiddleware 测试按键限流、响应头和拒绝处理
+func TestMiddleware(t *testing.T) {
+	_, group := newTestGroup(t, time.Unix(1700000000, 0))
+	limiter := New(group, "limiter")
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	byUser := func(r *http.Request) string { return r.Header.Get("X-User") }
+	handler := Middleware(limiter, Limit{Rate: 2, Period: time.Second}, byUser)(ok)
+
+	do := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("alice"); rec.Code != http.StatusOK {
+			t.Fatalf("第%d个请求应被允许，状态码为%d", i+1, rec.Code)
+		}
+	}
+	rec := do("alice")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("超过限制时应返回429，实际为%d", rec.Code)
+	}
+	h := rec.Header()
+	if h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != "0" ||
+		h.Get("Retry-After") != "1" || h.Get("X-RateLimit-Reset") != "1" {
+		t.Errorf("响应头不符: %v", h)
+	}
+
+	if rec := do("bob"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Errorf("不同的键应独立限流: %d %v", rec.Code, rec.Header())
+	}
+	// 键为空时不限流
+	for i := 0; i < 3; i++ {
+		if rec := do(""); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Errorf("键为空时不应限流: %d", rec.Code)
+		}
+	}
+}
+
+// TestMiddlewareHandlers 测试自定义拒绝处理器和出错处理
+func TestMiddlewareHandlers(t *testing.T) {
+	s, group := newTestGroup(t, time.Unix(1700000000, 0))
+	limiter := New(group, "limiter")
+
+	next := 0
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { next++ })
+	key := func(r *http.Request) string { return "k" }
+
+	denied := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	handler := Middleware(limiter, PerMinute(1), key, WithDeniedHandler(denied))(ok)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("应使用自定义拒绝处理器: %d %v", rec.Code, rec.Header())
+	}
+
+	// Redis不可用时默认放行
+	s.Close()
+	next = 0
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if next != 1 {
+		t.Error("限流出错时默认应放行")
+	}
+
+	var gotErr error
+	strict := Middleware(limiter, PerMinute(1), key, WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}))(ok)
+	rec = httptest.NewRecorder()
+	strict.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError || gotErr == nil || next != 1 {
+		t.Errorf("应使用出错处理函数: %d %v", rec.Code, gotErr)
+	}
+}
@@ -0,0 +1,279 @@
+// Package ratelimit 基于mgredis组中命名客户端的分布式限流
+//
+// 限流通过Lua脚本在Redis中原子地执行，时间取自Redis服务端的TIME，不受应用服务器时钟偏差影响。
+// 支持两种算法：
+//   - GCRA：通用信元速率算法，等价于令牌桶，每个键只保存一个时间戳，允许Burst个请求的突发
+//   - SlidingLog：滑动日志，在有序集合中记录窗口内每个请求的时间，任意Period内最多Rate个请求，
+//     结果精确但内存占用与Rate成正比
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qq1060656096/mgredis"
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm 限流算法
+type Algorithm string
+
+const (
+	// GCRA 通用信元速率算法（令牌桶），默认算法
+	GCRA Algorithm = "gcra"
+
+	// SlidingLog 滑动日志算法
+	SlidingLog Algorithm = "sliding_log"
+)
+
+// ErrInvalidLimit 限流参数无效
+var ErrInvalidLimit = errors.New("mgredis/ratelimit: invalid limit")
+
+// IsErrInvalidLimit 判断是否为限流参数无效错误
+func IsErrInvalidLimit(err error) bool {
+	return errors.Is(err, ErrInvalidLimit)
+}
+
+// Limit 限流规则：每Period最多Rate个请求
+type Limit struct {
+	// Rate 每个周期允许的请求数
+	Rate int
+	// Period 周期
+	Period time.Duration
+	// Burst 允许的突发请求数，仅对GCRA有效，为0时等于Rate
+	Burst int
+}
+
+// PerSecond 每秒最多rate个请求
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute 每分钟最多rate个请求
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// PerHour 每小时最多rate个请求
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+// burst 返回GCRA的突发容量
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// String 返回"10/1s"格式的字符串
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Rate, l.Period)
+}
+
+// Result 限流结果
+type Result struct {
+	// Limit 使用的限流规则
+	Limit Limit
+	// Allowed 请求是否被允许
+	Allowed bool
+	// Remaining 剩余可用的请求数
+	Remaining int
+	// RetryAfter 请求被拒绝时，需要等待多久才能被允许；允许时为0
+	RetryAfter time.Duration
+	// ResetAfter 多久之后配额完全恢复
+	ResetAfter time.Duration
+}
+
+var (
+	// gcraScript 按GCRA计算理论到达时间（TAT），允许时保存新的TAT
+	// 时间单位为微秒，返回 {allowed, remaining, retry_after, reset_after}
+	gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local interval = period / rate
+local tat = tonumber(redis.call("GET", key)) or now
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval * cost
+local diff = now - (new_tat - interval * burst)
+if diff < 0 then
+	return {0, math.floor((now - (tat - interval * burst)) / interval), math.ceil(-diff), math.ceil(tat - now)}
+end
+
+local reset_after = math.ceil(new_tat - now)
+redis.call("SET", key, string.format("%.0f", new_tat), "PX", math.ceil(reset_after / 1000))
+return {1, math.floor(diff / interval), 0, reset_after}`)
+
+	// slidingLogScript 删除窗口外的记录，窗口内记录数加cost不超过rate时记录本次请求
+	// 时间单位为微秒，返回 {allowed, remaining, retry_after, reset_after}
+	slidingLogScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local id = ARGV[4]
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - period)
+local count = redis.call("ZCARD", key)
+
+local allowed = 0
+if count + cost <= rate then
+	for i = 1, cost do
+		redis.call("ZADD", key, now, id .. ":" .. i)
+	end
+	redis.call("PEXPIRE", key, math.ceil(period / 1000))
+	count = count + cost
+	allowed = 1
+end
+
+local retry_after = 0
+if allowed == 0 then
+	local entry = redis.call("ZRANGE", key, count + cost - rate - 1, count + cost - rate - 1, "WITHSCORES")
+	retry_after = tonumber(entry[2]) + period - now
+end
+
+local reset_after = 0
+local newest = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+if newest[2] then
+	reset_after = tonumber(newest[2]) + period - now
+end
+local remaining = rate - count
+if remaining < 0 then
+	remaining = 0
+end
+return {allowed, remaining, retry_after, reset_after}`)
+)
+
+// Option 限流选项
+type Option func(*options)
+
+type options struct {
+	prefix    string
+	algorithm Algorithm
+}
+
+// WithAlgorithm 设置限流算法，默认为GCRA
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(o *options) {
+		o.algorithm = algorithm
+	}
+}
+
+// WithKeyPrefix 设置限流键的前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// Limiter 使用组中指定名称的客户端进行限流
+//
+// 每次操作都通过Group.Get获取客户端，客户端惰性创建，Reconfigure后自动使用新客户端。
+type Limiter struct {
+	group mgredis.Group
+	name  string
+	opts  options
+}
+
+// New 创建Limiter，name为组中已注册的客户端名称
+func New(group mgredis.Group, name string, opts ...Option) *Limiter {
+	o := options{algorithm: GCRA}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{group: group, name: name, opts: o}
+}
+
+// Allow 判断key的一个请求是否被允许
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN 判断key的n个请求是否被允许，n个请求要么全部允许要么全部拒绝
+// n超过突发容量（GCRA为Burst，SlidingLog为Rate）时永远无法满足，返回ErrInvalidLimit
+func (l *Limiter) AllowN(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	if limit.Rate <= 0 || limit.Period < time.Millisecond {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLimit, limit)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: n must be positive, got %d", ErrInvalidLimit, n)
+	}
+
+	client, err := l.group.Get(ctx, l.name)
+	if err != nil {
+		return nil, err
+	}
+
+	key = l.opts.prefix + key
+	period := limit.Period.Microseconds()
+	var values []int64
+	switch l.opts.algorithm {
+	case GCRA:
+		if n > limit.burst() {
+			return nil, fmt.Errorf("%w: n %d exceeds burst %d", ErrInvalidLimit, n, limit.burst())
+		}
+		values, err = gcraScript.Run(ctx, client, []string{key}, limit.burst(), limit.Rate, period, n).Int64Slice()
+	case SlidingLog:
+		if n > limit.Rate {
+			return nil, fmt.Errorf("%w: n %d exceeds rate %d", ErrInvalidLimit, n, limit.Rate)
+		}
+		id, idErr := newID()
+		if idErr != nil {
+			return nil, idErr
+		}
+		values, err = slidingLogScript.Run(ctx, client, []string{key}, limit.Rate, period, n, id).Int64Slice()
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidLimit, l.opts.algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Limit:      limit,
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// Reset 清除key的限流状态
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	client, err := l.group.Get(ctx, l.name)
+	if err != nil {
+		return err
+	}
+	return client.Del(ctx, l.opts.prefix+key).Err()
+}
+
+// newID 生成本次请求在滑动日志中的唯一标识
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/qq1060656096/mgredis"
+)
+
+// newTestGroup 启动miniredis并注册名为limiter的客户端，服务端时间固定为now
+func newTestGroup(t *testing.T, now time.Time) (*miniredis.Miniredis, mgredis.Group) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	s.SetTime(now)
+	group := mgredis.New()
+	t.Cleanup(func() { group.Close(context.Background()) })
+	_, _ = group.Register(context.Background(), "limiter", mgredis.RedisConfig{Addr: s.Addr()})
+	return s, group
+}
+
+// TestGCRA 测试令牌桶的突发、拒绝和恢复
+func TestGCRA(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s, group := newTestGroup(t, now)
+	limiter := New(group, "limiter", WithKeyPrefix("rl:"))
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 5}
+
+	// 允许Burst个请求的突发
+	for i := 0; i < 5; i++ {
+		res, err := limiter.Allow(ctx, "user:1", limit)
+		if err != nil {
+			t.Fatalf("限流失败: %v", err)
+		}
+		if !res.Allowed || res.Remaining != 4-i || res.RetryAfter != 0 {
+			t.Fatalf("第%d个请求结果不符: %+v", i+1, res)
+		}
+	}
+	if !s.Exists("rl:user:1") {
+		t.Error("应保存限流状态")
+	}
+
+	res, _ := limiter.Allow(ctx, "user:1", limit)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 100*time.Millisecond || res.ResetAfter != 500*time.Millisecond {
+		t.Errorf("超过突发容量时应拒绝: %+v", res)
+	}
+
+	// 每100ms恢复一个
+	s.SetTime(now.Add(250 * time.Millisecond))
+	res, _ = limiter.AllowN(ctx, "user:1", limit, 2)
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("恢复后应允许: %+v", res)
+	}
+	res, _ = limiter.Allow(ctx, "user:1", limit)
+	if res.Allowed || res.RetryAfter != 50*time.Millisecond {
+		t.Errorf("预期50ms后重试: %+v", res)
+	}
+
+	// 不同的键互不影响
+	if res, _ := limiter.Allow(ctx, "user:2", limit); !res.Allowed || res.Remaining != 4 {
+		t.Errorf("不同的键应独立限流: %+v", res)
+	}
+
+	if err := limiter.Reset(ctx, "user:1"); err != nil {
+		t.Fatalf("重置失败: %v", err)
+	}
+	if res, _ := limiter.Allow(ctx, "user:1", limit); !res.Allowed || res.Remaining != 4 {
+		t.Errorf("重置后应恢复全部配额: %+v", res)
+	}
+}
+
+// TestSlidingLog 测试滑动日志在窗口内的精确计数
+func TestSlidingLog(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s, group := newTestGroup(t, now)
+	limiter := New(group, "limiter", WithAlgorithm(SlidingLog))
+	limit := PerSecond(3)
+
+	for i := 0; i < 2; i++ {
+		if res, _ := limiter.Allow(ctx, "k", limit); !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("第%d个请求结果不符: %+v", i+1, res)
+		}
+	}
+	s.SetTime(now.Add(400 * time.Millisecond))
+	res, _ := limiter.Allow(ctx, "k", limit)
+	if !res.Allowed || res.Remaining != 0 || res.ResetAfter != time.Second {
+		t.Fatalf("第3个请求结果不符: %+v", res)
+	}
+
+	// 窗口已满，等到最早的请求移出窗口
+	res, _ = limiter.Allow(ctx, "k", limit)
+	if res.Allowed || res.RetryAfter != 600*time.Millisecond || res.ResetAfter != time.Second {
+		t.Errorf("窗口已满时应拒绝: %+v", res)
+	}
+	res, _ = limiter.AllowN(ctx, "k", limit, 3)
+	if res.Allowed || res.RetryAfter != time.Second {
+		t.Errorf("需要所有请求移出窗口: %+v", res)
+	}
+
+	// 最早的两个请求移出窗口
+	s.SetTime(now.Add(time.Second))
+	res, _ = limiter.AllowN(ctx, "k", limit, 2)
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("请求移出窗口后应允许: %+v", res)
+	}
+	if n, _ := s.ZMembers("k"); len(n) != 3 {
+		t.Errorf("窗口内应有3条记录，实际为%d", len(n))
+	}
+}
+
+// TestInvalidLimit 测试无效的限流参数
+func TestInvalidLimit(t *testing.T) {
+	ctx := context.Background()
+	_, group := newTestGroup(t, time.Now())
+
+	gcra := New(group, "limiter")
+	sliding := New(group, "limiter", WithAlgorithm(SlidingLog))
+	cases := []struct {
+		limiter *Limiter
+		limit   Limit
+		n       int
+	}{
+		{gcra, Limit{Rate: 0, Period: time.Second}, 1},
+		{gcra, Limit{Rate: 1, Period: 0}, 1},
+		{gcra, PerSecond(10), 0},
+		{gcra, Limit{Rate: 10, Period: time.Second, Burst: 2}, 3},
+		{sliding, PerSecond(2), 3},
+		{New(group, "limiter", WithAlgorithm("fixed")), PerSecond(1), 1},
+	}
+	for _, c := range cases {
+		if _, err := c.limiter.AllowN(ctx, "k", c.limit, c.n); !IsErrInvalidLimit(err) {
+			t.Errorf("%s n=%d: 预期ErrInvalidLimit，实际为: %v", c.limit, c.n, err)
+		}
+	}
+
+	if _, err := New(group, "missing").Allow(ctx, "k", PerSecond(1)); !mgredis.IsErrClientNotFound(err) {
+		t.Errorf("预期ErrClientNotFound，实际为: %v", err)
+	}
+}
@@ -0,0 +1,482 @@
+package mgredis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ReplicaRoundRobin 在可用的从节点间轮询
+	ReplicaRoundRobin = "round_robin"
+
+	// ReplicaLeastLatency 选择最近一次检查延迟最低的可用从节点
+	ReplicaLeastLatency = "least_latency"
+
+	// DefaultReplicaCheckInterval 未通过WithHealthCheck设置间隔时，检查从节点的默认间隔
+	DefaultReplicaCheckInterval = time.Second
+)
+
+// checkReplicaSelection 检查从节点选择策略
+func checkReplicaSelection(selection string) error {
+	switch selection {
+	case "", ReplicaRoundRobin, ReplicaLeastLatency:
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported replica_selection %q", ErrInvalidConfig, selection)
+	}
+}
+
+// ReadWriteClient 读写分离客户端
+//
+// 内嵌主节点客户端，写命令、事务（TxPipelined、Watch）、流水线以及未单独列出的命令都在主节点上执行；
+// GET、MGET、HGETALL、ZRANGE等只读命令路由到可用的从节点，其他只读命令可通过Replica()执行。
+// 从节点在后台定期检查，连续失败达到不可用阈值（见WithHealthThresholds）后跳过，所有从节点都不可用时使用主节点。
+//
+// 客户端由组管理，不要调用Close；主节点客户端被替换或注销时，从节点客户端在排空等待时间后关闭，
+// 组关闭时立即关闭。
+type ReadWriteClient struct {
+	*redis.Client
+
+	replicas     []*replica
+	leastLatency bool
+	counter      atomic.Uint64
+	health       healthOptions
+	interval     time.Duration
+	gracePeriod  time.Duration
+
+	stopOnce  sync.Once
+	closeOnce sync.Once
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+// replica 从节点及其检查状态
+type replica struct {
+	addr   string
+	client *redis.Client
+
+	mu       sync.Mutex
+	down     bool
+	failures int
+	latency  time.Duration
+	err      error
+}
+
+// ReplicaStatus 从节点状态
+type ReplicaStatus struct {
+	// Addr 从节点地址
+	Addr string
+	// Healthy 是否可用
+	Healthy bool
+	// Latency 最近一次Ping的延迟
+	Latency time.Duration
+	// Err 最近一次检查的错误，成功时为nil
+	Err error
+}
+
+// GetReadWrite 获取读写分离客户端，同一主节点客户端对应的读写分离客户端会被复用
+func (g *group) GetReadWrite(ctx context.Context, name string) (*ReadWriteClient, error) {
+	primary, err := g.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := g.Config(ctx, name)
+	if err != nil {
+		return nil, notFoundError(OpGet, g.key(name), err)
+	}
+
+	g.state.rwMu.Lock()
+	defer g.state.rwMu.Unlock()
+
+	if rw, ok := g.state.readWrite[name]; ok {
+		if rw.Client == primary {
+			return rw, nil
+		}
+		rw.close()
+	}
+	rw, err := g.newReadWriteClient(ctx, name, cfg, primary)
+	if err != nil {
+		return nil, err
+	}
+	if g.state.readWrite == nil {
+		g.state.readWrite = make(map[string]*ReadWriteClient)
+	}
+	g.state.readWrite[name] = rw
+	return rw, nil
+}
+
+// newReadWriteClient 为主节点客户端创建从节点客户端并开始检查
+// 从节点客户端不进行Ping测试，暂时不可用的从节点由后台检查跳过
+func (g *group) newReadWriteClient(ctx context.Context, name string, cfg RedisConfig, primary *redis.Client) (*ReadWriteClient, error) {
+	rw := &ReadWriteClient{
+		Client:       primary,
+		leastLatency: cfg.ReplicaSelection == ReplicaLeastLatency,
+		health:       g.opts.health,
+		interval:     g.opts.health.interval,
+		gracePeriod:  g.opts.gracePeriod,
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if rw.interval <= 0 {
+		rw.interval = DefaultReplicaCheckInterval
+	}
+
+	for _, addr := range cfg.Replicas {
+		rc := cfg.withAddr(addr)
+		client, err := buildClient(ctx, rc)
+		if err != nil {
+			for _, r := range rw.replicas {
+				_ = r.client.Close()
+			}
+			return nil, instanceError(OpOpen, g.key(name), addr, err)
+		}
+		g.opts.instrument(g.key(name), addr, rc, client)
+		rw.replicas = append(rw.replicas, &replica{addr: addr, client: client})
+	}
+
+	go rw.run()
+	return rw, nil
+}
+
+// Primary 返回主节点客户端
+func (c *ReadWriteClient) Primary() *redis.Client {
+	return c.Client
+}
+
+// Replica 按选择策略返回一个可用的从节点客户端，没有可用的从节点时返回主节点客户端
+func (c *ReadWriteClient) Replica() *redis.Client {
+	n := len(c.replicas)
+	if n == 0 {
+		return c.Client
+	}
+
+	var best *replica
+	var bestLatency time.Duration
+	if c.leastLatency {
+		for _, r := range c.replicas {
+			r.mu.Lock()
+			down, latency := r.down, r.latency
+			r.mu.Unlock()
+			if !down && (best == nil || latency < bestLatency) {
+				best, bestLatency = r, latency
+			}
+		}
+	} else {
+		start := c.counter.Add(1)
+		for i := 0; i < n; i++ {
+			r := c.replicas[(start+uint64(i))%uint64(n)]
+			r.mu.Lock()
+			down := r.down
+			r.mu.Unlock()
+			if !down {
+				best = r
+				break
+			}
+		}
+	}
+
+	if best == nil {
+		return c.Client
+	}
+	return best.client
+}
+
+// Replicas 返回所有从节点的状态，顺序与RedisConfig.Replicas一致
+func (c *ReadWriteClient) Replicas() []ReplicaStatus {
+	list := make([]ReplicaStatus, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		r.mu.Lock()
+		list = append(list, ReplicaStatus{Addr: r.addr, Healthy: !r.down, Latency: r.latency, Err: r.err})
+		r.mu.Unlock()
+	}
+	return list
+}
+
+// run 定期检查从节点，启动后立即检查一次
+func (c *ReadWriteClient) run() {
+	defer close(c.done)
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.checkReplicas()
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReplicas 并发检查所有从节点
+func (c *ReadWriteClient) checkReplicas() {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), c.health.timeout)
+			start := time.Now()
+			err := r.client.Ping(ctx).Err()
+			latency := time.Since(start)
+			cancel()
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.latency, r.err = latency, err
+			if err != nil {
+				r.failures++
+				r.down = r.failures >= c.health.downAfter
+				return
+			}
+			r.failures, r.down = 0, false
+		}(r)
+	}
+	wg.Wait()
+}
+
+// close 停止检查，从节点客户端在排空等待时间后关闭
+func (c *ReadWriteClient) close() {
+	c.stop()
+	if c.gracePeriod <= 0 {
+		go c.closeReplicas()
+		return
+	}
+	time.AfterFunc(c.gracePeriod, c.closeReplicas)
+}
+
+// closeNow 停止检查并立即关闭从节点客户端，用于组关闭
+func (c *ReadWriteClient) closeNow() {
+	c.stop()
+	c.closeReplicas()
+}
+
+// stop 停止后台检查
+func (c *ReadWriteClient) stop() {
+	c.stopOnce.Do(func() {
+		close(c.quit)
+	})
+}
+
+// closeReplicas 等待后台检查退出后关闭所有从节点客户端，只执行一次
+func (c *ReadWriteClient) closeReplicas() {
+	<-c.done
+	c.closeOnce.Do(func() {
+		for _, r := range c.replicas {
+			_ = r.client.Close()
+		}
+	})
+}
+
+// Get 在从节点上执行
+func (c *ReadWriteClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	return c.Replica().Get(ctx, key)
+}
+
+// MGet 在从节点上执行
+func (c *ReadWriteClient) MGet(ctx context.Context, keys ...string) *redis.SliceCmd {
+	return c.Replica().MGet(ctx, keys...)
+}
+
+// Exists 在从节点上执行
+func (c *ReadWriteClient) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	return c.Replica().Exists(ctx, keys...)
+}
+
+// StrLen 在从节点上执行
+func (c *ReadWriteClient) StrLen(ctx context.Context, key string) *redis.IntCmd {
+	return c.Replica().StrLen(ctx, key)
+}
+
+// GetRange 在从节点上执行
+func (c *ReadWriteClient) GetRange(ctx context.Context, key string, start, end int64) *redis.StringCmd {
+	return c.Replica().GetRange(ctx, key, start, end)
+}
+
+// GetBit 在从节点上执行
+func (c *ReadWriteClient) GetBit(ctx context.Context, key string, offset int64) *redis.IntCmd {
+	return c.Replica().GetBit(ctx, key, offset)
+}
+
+// BitCount 在从节点上执行
+func (c *ReadWriteClient) BitCount(ctx context.Context, key string, bitCount *redis.BitCount) *redis.IntCmd {
+	return c.Replica().BitCount(ctx, key, bitCount)
+}
+
+// TTL 在从节点上执行
+func (c *ReadWriteClient) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	return c.Replica().TTL(ctx, key)
+}
+
+// PTTL 在从节点上执行
+func (c *ReadWriteClient) PTTL(ctx context.Context, key string) *redis.DurationCmd {
+	return c.Replica().PTTL(ctx, key)
+}
+
+// Type 在从节点上执行
+func (c *ReadWriteClient) Type(ctx context.Context, key string) *redis.StatusCmd {
+	return c.Replica().Type(ctx, key)
+}
+
+// Keys 在从节点上执行
+func (c *ReadWriteClient) Keys(ctx context.Context, pattern string) *redis.StringSliceCmd {
+	return c.Replica().Keys(ctx, pattern)
+}
+
+// Scan 在从节点上执行
+func (c *ReadWriteClient) Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.Replica().Scan(ctx, cursor, match, count)
+}
+
+// HGet 在从节点上执行
+func (c *ReadWriteClient) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	return c.Replica().HGet(ctx, key, field)
+}
+
+// HGetAll 在从节点上执行
+func (c *ReadWriteClient) HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd {
+	return c.Replica().HGetAll(ctx, key)
+}
+
+// HMGet 在从节点上执行
+func (c *ReadWriteClient) HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd {
+	return c.Replica().HMGet(ctx, key, fields...)
+}
+
+// HExists 在从节点上执行
+func (c *ReadWriteClient) HExists(ctx context.Context, key, field string) *redis.BoolCmd {
+	return c.Replica().HExists(ctx, key, field)
+}
+
+// HLen 在从节点上执行
+func (c *ReadWriteClient) HLen(ctx context.Context, key string) *redis.IntCmd {
+	return c.Replica().HLen(ctx, key)
+}
+
+// HKeys 在从节点上执行
+func (c *ReadWriteClient) HKeys(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.Replica().HKeys(ctx, key)
+}
+
+// HVals 在从节点上执行
+func (c *ReadWriteClient) HVals(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.Replica().HVals(ctx, key)
+}
+
+// HScan 在从节点上执行
+func (c *ReadWriteClient) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.Replica().HScan(ctx, key, cursor, match, count)
+}
+
+// LRange 在从节点上执行
+func (c *ReadWriteClient) LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.Replica().LRange(ctx, key, start, stop)
+}
+
+// LLen 在从节点上执行
+func (c *ReadWriteClient) LLen(ctx context.Context, key string) *redis.IntCmd {
+	return c.Replica().LLen(ctx, key)
+}
+
+// LIndex 在从节点上执行
+func (c *ReadWriteClient) LIndex(ctx context.Context, key string, index int64) *redis.StringCmd {
+	return c.Replica().LIndex(ctx, key, index)
+}
+
+// SMembers 在从节点上执行
+func (c *ReadWriteClient) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.Replica().SMembers(ctx, key)
+}
+
+// SIsMember 在从节点上执行
+func (c *ReadWriteClient) SIsMember(ctx context.Context, key string, member interface{}) *redis.BoolCmd {
+	return c.Replica().SIsMember(ctx, key, member)
+}
+
+// SMIsMember 在从节点上执行
+func (c *ReadWriteClient) SMIsMember(ctx context.Context, key string, members ...interface{}) *redis.BoolSliceCmd {
+	return c.Replica().SMIsMember(ctx, key, members...)
+}
+
+// SCard 在从节点上执行
+func (c *ReadWriteClient) SCard(ctx context.Context, key string) *redis.IntCmd {
+	return c.Replica().SCard(ctx, key)
+}
+
+// SScan 在从节点上执行
+func (c *ReadWriteClient) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.Replica().SScan(ctx, key, cursor, match, count)
+}
+
+// ZRange 在从节点上执行
+func (c *ReadWriteClient) ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.Replica().ZRange(ctx, key, start, stop)
+}
+
+// ZRangeWithScores 在从节点上执行
+func (c *ReadWriteClient) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	return c.Replica().ZRangeWithScores(ctx, key, start, stop)
+}
+
+// ZRevRange 在从节点上执行
+func (c *ReadWriteClient) ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.Replica().ZRevRange(ctx, key, start, stop)
+}
+
+// ZRangeByScore 在从节点上执行
+func (c *ReadWriteClient) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.Replica().ZRangeByScore(ctx, key, opt)
+}
+
+// ZRevRangeByScore 在从节点上执行
+func (c *ReadWriteClient) ZRevRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.Replica().ZRevRangeByScore(ctx, key, opt)
+}
+
+// ZScore 在从节点上执行
+func (c *ReadWriteClient) ZScore(ctx context.Context, key, member string) *redis.FloatCmd {
+	return c.Replica().ZScore(ctx, key, member)
+}
+
+// ZMScore 在从节点上执行
+func (c *ReadWriteClient) ZMScore(ctx context.Context, key string, members ...string) *redis.FloatSliceCmd {
+	return c.Replica().ZMScore(ctx, key, members...)
+}
+
+// ZCard 在从节点上执行
+func (c *ReadWriteClient) ZCard(ctx context.Context, key string) *redis.IntCmd {
+	return c.Replica().ZCard(ctx, key)
+}
+
+// ZCount 在从节点上执行
+func (c *ReadWriteClient) ZCount(ctx context.Context, key, min, max string) *redis.IntCmd {
+	return c.Replica().ZCount(ctx, key, min, max)
+}
+
+// ZRank 在从节点上执行
+func (c *ReadWriteClient) ZRank(ctx context.Context, key, member string) *redis.IntCmd {
+	return c.Replica().ZRank(ctx, key, member)
+}
+
+// ZRevRank 在从节点上执行
+func (c *ReadWriteClient) ZRevRank(ctx context.Context, key, member string) *redis.IntCmd {
+	return c.Replica().ZRevRank(ctx, key, member)
+}
+
+// ZScan 在从节点上执行
+func (c *ReadWriteClient) ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) *redis.ScanCmd {
+	return c.Replica().ZScan(ctx, key, cursor, match, count)
+}
+
+// PFCount 在从节点上执行
+func (c *ReadWriteClient) PFCount(ctx context.Context, keys ...string) *redis.IntCmd {
+	return c.Replica().PFCount(ctx, keys...)
+}
@@ -0,0 +1,277 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newReplicaRedis 启动一个对GET返回value的fakeRedis，down为true时PING返回错误，delay为PING的延迟
+func newReplicaRedis(t *testing.T, value string, down *atomic.Bool, delay time.Duration) *fakeRedis {
+	return newFakeRedis(t, func(args []string) (interface{}, bool) {
+		switch strings.ToUpper(args[0]) {
+		case "GET", "HGET":
+			return value, true
+		case "SET", "MULTI", "EXEC":
+			return nil, false
+		case "PING":
+			time.Sleep(delay)
+			if down != nil && down.Load() {
+				return respError("ERR injected failure"), true
+			}
+		}
+		return nil, false
+	})
+}
+
+// waitReplicas 等待从节点状态满足条件
+func waitReplicas(t *testing.T, rw *ReadWriteClient, ok func([]ReplicaStatus) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if ok(rw.Replicas()) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("等待从节点状态超时: %+v", rw.Replicas())
+}
+
+// TestReadWriteClient 测试只读命令路由到从节点、写命令路由到主节点
+func TestReadWriteClient(t *testing.T) {
+	ctx := context.Background()
+
+	var down atomic.Bool
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	r1 := newReplicaRedis(t, "r1", &down, 0)
+	r2 := newReplicaRedis(t, "r2", nil, 0)
+
+	group := New(WithHealthCheck(10*time.Millisecond), WithHealthThresholds(time.Second, 1))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:     primary.Addr(),
+		Replicas: []string{r1.Addr(), r2.Addr()},
+	})
+	rw, err := group.GetReadWrite(ctx, "cache")
+	if err != nil {
+		t.Fatalf("获取读写分离客户端失败: %v", err)
+	}
+	if rw.Primary() != group.MustGet(ctx, "cache") {
+		t.Error("主节点应为组中的客户端")
+	}
+	if again, _ := group.GetReadWrite(ctx, "cache"); again != rw {
+		t.Error("同一主节点应复用读写分离客户端")
+	}
+
+	// 轮询从节点
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[rw.Get(ctx, "k").Val()]++
+	}
+	if seen["r1"] != 2 || seen["r2"] != 2 {
+		t.Errorf("只读命令应在从节点间轮询: %v", seen)
+	}
+
+	// 写命令和事务在主节点执行
+	before := len(primary.Commands())
+	_ = rw.Set(ctx, "k", "v", 0).Err()
+	_, _ = rw.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Get(ctx, "k")
+		return nil
+	})
+	cmds := strings.Join(primary.Commands()[before:], " ")
+	if !strings.Contains(cmds, "SET") || !strings.Contains(cmds, "MULTI GET EXEC") {
+		t.Errorf("写命令和事务应在主节点执行: %s", cmds)
+	}
+	for _, r := range []*fakeRedis{r1, r2} {
+		for _, cmd := range r.Commands() {
+			if cmd == "SET" || cmd == "MULTI" {
+				t.Errorf("从节点不应收到写命令: %s", cmd)
+			}
+		}
+	}
+
+	// 不可用的从节点被跳过
+	down.Store(true)
+	waitReplicas(t, rw, func(list []ReplicaStatus) bool { return !list[0].Healthy && list[1].Healthy })
+	for i := 0; i < 4; i++ {
+		if got := rw.HGet(ctx, "h", "f").Val(); got != "r2" {
+			t.Fatalf("应跳过不可用的从节点，实际为%q", got)
+		}
+	}
+
+	// 所有从节点都不可用时使用主节点
+	r2.Close()
+	waitReplicas(t, rw, func(list []ReplicaStatus) bool { return !list[0].Healthy && !list[1].Healthy })
+	if got := rw.Get(ctx, "k").Val(); got != "primary" {
+		t.Errorf("没有可用从节点时应使用主节点，实际为%q", got)
+	}
+}
+
+// TestReadWriteClientLeastLatency 测试选择延迟最低的从节点
+func TestReadWriteClientLeastLatency(t *testing.T) {
+	ctx := context.Background()
+
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	slow := newReplicaRedis(t, "slow", nil, 30*time.Millisecond)
+	fast := newReplicaRedis(t, "fast", nil, 0)
+
+	group := New(WithHealthCheck(10 * time.Millisecond))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:             primary.Addr(),
+		Replicas:         []string{slow.Addr(), fast.Addr()},
+		ReplicaSelection: ReplicaLeastLatency,
+	})
+	rw := mustGetReadWrite(t, group, "cache")
+	waitReplicas(t, rw, func(list []ReplicaStatus) bool { return list[0].Latency > list[1].Latency && list[1].Latency > 0 })
+	for i := 0; i < 3; i++ {
+		if got := rw.Get(ctx, "k").Val(); got != "fast" {
+			t.Errorf("应选择延迟最低的从节点，实际为%q", got)
+		}
+	}
+
+	_, _ = group.Register(ctx, "invalid", RedisConfig{Addr: primary.Addr(), ReplicaSelection: "random"})
+	if _, err := group.GetReadWrite(ctx, "invalid"); !IsErrInvalidConfig(err) {
+		t.Errorf("预期ErrInvalidConfig，实际为: %v", err)
+	}
+}
+
+// TestReadWriteClientReconfigure 测试主节点客户端替换后重新创建读写分离客户端
+func TestReadWriteClientReconfigure(t *testing.T) {
+	ctx := context.Background()
+
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	r1 := newReplicaRedis(t, "r1", nil, 0)
+	r2 := newReplicaRedis(t, "r2", nil, 0)
+
+	group := New(WithGracePeriod(0))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: primary.Addr(), Replicas: []string{r1.Addr()}})
+	old := mustGetReadWrite(t, group, "cache")
+	if got := old.Get(ctx, "k").Val(); got != "r1" {
+		t.Fatalf("预期r1，实际为%q", got)
+	}
+
+	if err := group.Reconfigure(ctx, "cache", RedisConfig{Addr: primary.Addr(), Replicas: []string{r2.Addr()}}); err != nil {
+		t.Fatalf("替换失败: %v", err)
+	}
+	rw := mustGetReadWrite(t, group, "cache")
+	if rw == old {
+		t.Fatal("主节点替换后应创建新的读写分离客户端")
+	}
+	if got := rw.Get(ctx, "k").Val(); got != "r2" {
+		t.Errorf("预期r2，实际为%q", got)
+	}
+
+	// 旧的从节点客户端被关闭
+	deadline := time.Now().Add(time.Second)
+	for old.replicas[0].client.Ping(ctx).Err() == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := old.replicas[0].client.Ping(ctx).Err(); err == nil {
+		t.Error("旧的从节点客户端应被关闭")
+	}
+}
+
+// TestReadWriteClientCheckTimeout 测试从节点检查受健康检查超时限制，不等待ReadTimeout
+func TestReadWriteClientCheckTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	var hang atomic.Bool
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	r1 := newHangingRedis(t, &hang)
+
+	group := New(
+		WithHealthCheck(20*time.Millisecond),
+		WithHealthCheckTimeout(100*time.Millisecond),
+		WithHealthThresholds(time.Second, 1),
+	)
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{
+		Addr:        primary.Addr(),
+		Replicas:    []string{r1.Addr()},
+		ReadTimeout: 10 * time.Second,
+	})
+	rw := mustGetReadWrite(t, group, "cache")
+	waitReplicas(t, rw, func(list []ReplicaStatus) bool { return list[0].Healthy && list[0].Latency > 0 })
+
+	hang.Store(true)
+	start := time.Now()
+	waitReplicas(t, rw, func(list []ReplicaStatus) bool { return !list[0].Healthy })
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("从节点检查应在超时时间内完成，实际耗时%v", elapsed)
+	}
+}
+
+// TestReadWriteClientMetrics 测试从节点命令的指标带有replica标签
+func TestReadWriteClientMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	r1 := newReplicaRedis(t, "r1", nil, 0)
+
+	metrics := NewMetrics()
+	group := New(WithMetrics(metrics))
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: primary.Addr(), Replicas: []string{r1.Addr()}})
+	rw := mustGetReadWrite(t, group, "cache")
+	rw.Get(ctx, "k")
+	rw.Set(ctx, "k", "v", 0)
+
+	var sb strings.Builder
+	if _, err := metrics.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo失败: %v", err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		`mgredis_command_duration_seconds_count{group="defaultGroup",name="cache",replica="` + r1.Addr() + `",command="get"} 1`,
+		`mgredis_command_duration_seconds_count{group="defaultGroup",name="cache",command="set"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出缺少%q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `name="cache",command="get"`) {
+		t.Errorf("从节点命令不应计入主节点指标\n%s", out)
+	}
+}
+
+// TestReadWriteClientGroupClose 测试组关闭时立即关闭从节点客户端，不等待排空时间
+func TestReadWriteClientGroupClose(t *testing.T) {
+	ctx := context.Background()
+
+	primary := newReplicaRedis(t, "primary", nil, 0)
+	r1 := newReplicaRedis(t, "r1", nil, 0)
+
+	group := New(WithGracePeriod(time.Hour))
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: primary.Addr(), Replicas: []string{r1.Addr()}})
+	rw := mustGetReadWrite(t, group, "cache")
+	if got := rw.Get(ctx, "k").Val(); got != "r1" {
+		t.Fatalf("预期r1，实际为%q", got)
+	}
+
+	group.Close(ctx)
+	if err := rw.replicas[0].client.Ping(ctx).Err(); !errors.Is(err, redis.ErrClosed) {
+		t.Errorf("组关闭后从节点客户端应立即关闭，实际为: %v", err)
+	}
+}
+
+func mustGetReadWrite(t *testing.T, group Group, name string) *ReadWriteClient {
+	t.Helper()
+	rw, err := group.GetReadWrite(context.Background(), name)
+	if err != nil {
+		t.Fatalf("获取读写分离客户端失败: %v", err)
+	}
+	return rw
+}
@@ -0,0 +1,149 @@
+package mgredis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// TLSConfig TLS连接配置
+type TLSConfig struct {
+	// Enabled 是否启用TLS
+	Enabled bool `json:"enabled"`
+
+	// CAFile CA证书文件路径，用于校验服务端证书，为空时使用系统根证书
+	CAFile string `json:"ca_file"`
+
+	// CAPEM PEM格式的CA证书内容，与CAFile二选一
+	CAPEM string `json:"ca_pem"`
+
+	// CertFile 客户端证书文件路径，双向认证时与KeyFile一起设置
+	CertFile string `json:"cert_file"`
+
+	// KeyFile 客户端私钥文件路径
+	KeyFile string `json:"key_file"`
+
+	// CertPEM PEM格式的客户端证书内容，与CertFile二选一
+	CertPEM string `json:"cert_pem"`
+
+	// KeyPEM PEM格式的客户端私钥内容，与KeyFile二选一
+	KeyPEM string `json:"key_pem"`
+
+	// ServerName 校验服务端证书时使用的主机名，默认为所连接地址中的主机。
+	// Sentinel模式下默认取每次连接的Sentinel或主节点地址中的主机，
+	// 仅当Sentinel上报的是IP而证书签发给域名时才需显式设置，此时对所有节点生效
+	ServerName string `json:"server_name"`
+
+	// MinVersion 最低TLS版本，可选"1.0"、"1.1"、"1.2"、"1.3"，默认为1.2
+	MinVersion string `json:"min_version"`
+
+	// InsecureSkipVerify 跳过服务端证书校验，仅用于开发环境
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+}
+
+// tlsVersions TLS版本名称与常量的映射
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// CheckAndSetDefaults 检查TLS配置，未启用TLS时不做检查
+func (t *TLSConfig) CheckAndSetDefaults() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	if _, ok := tlsVersions[t.MinVersion]; t.MinVersion != "" && !ok {
+		return fmt.Errorf("%w: unsupported min_version %q", ErrInvalidTLSConfig, t.MinVersion)
+	}
+
+	if t.CAFile != "" && t.CAPEM != "" {
+		return fmt.Errorf("%w: ca_file and ca_pem are mutually exclusive", ErrInvalidTLSConfig)
+	}
+	if (t.CertFile != "" || t.KeyFile != "") && (t.CertPEM != "" || t.KeyPEM != "") {
+		return fmt.Errorf("%w: cert/key files and pem are mutually exclusive", ErrInvalidTLSConfig)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("%w: cert_file and key_file must be set together", ErrInvalidTLSConfig)
+	}
+	if (t.CertPEM == "") != (t.KeyPEM == "") {
+		return fmt.Errorf("%w: cert_pem and key_pem must be set together", ErrInvalidTLSConfig)
+	}
+
+	return nil
+}
+
+// Build 根据配置生成tls.Config，未启用TLS时返回nil
+// serverName 为ServerName未设置时使用的默认主机名
+func (t *TLSConfig) Build(serverName string) (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	c := &tls.Config{
+		MinVersion:         tlsVersions[t.MinVersion],
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // 仅用于开发环境
+	}
+	if c.MinVersion == 0 {
+		c.MinVersion = tls.VersionTLS12
+	}
+	if c.ServerName == "" {
+		c.ServerName = serverName
+	}
+
+	// 加载CA证书
+	caPEM := []byte(t.CAPEM)
+	if t.CAFile != "" {
+		data, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read ca_file: %v", ErrInvalidTLSConfig, err)
+		}
+		caPEM = data
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%w: no valid certificate found in ca", ErrInvalidTLSConfig)
+		}
+		c.RootCAs = pool
+	}
+
+	// 加载客户端证书
+	var (
+		cert tls.Certificate
+		err  error
+	)
+	switch {
+	case t.CertFile != "":
+		cert, err = tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	case t.CertPEM != "":
+		cert, err = tls.X509KeyPair([]byte(t.CertPEM), []byte(t.KeyPEM))
+	default:
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: load client certificate: %v", ErrInvalidTLSConfig, err)
+	}
+	c.Certificates = []tls.Certificate{cert}
+
+	return c, nil
+}
+
+// tlsServerName 返回默认的TLS主机名，即Addr中的主机部分
+// Sentinel模式下返回空字符串，由tls.DialWithDialer根据每次连接的地址推导
+func (cfg *RedisConfig) tlsServerName() string {
+	if cfg.IsSentinel() || cfg.Network == "unix" {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return cfg.Addr
+	}
+	return host
+}
@@ -0,0 +1,228 @@
+package mgredis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert 测试用证书及私钥的PEM内容
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCert 生成测试证书，parent为nil时生成自签名CA
+func newTestCert(t *testing.T, parent *testCert, isCA bool, extKeyUsage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mgredis-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if !isCA {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{extKeyUsage}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+// newFakeTLSRedis 启动TLS终结的fakeRedis，clientCA非nil时要求客户端证书
+func newFakeTLSRedis(t *testing.T, server, clientCA *testCert) *fakeRedis {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair([]byte(server.certPEM), []byte(server.keyPEM))
+	if err != nil {
+		t.Fatalf("加载服务端证书失败: %v", err)
+	}
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA.cert)
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatalf("启动TLS监听失败: %v", err)
+	}
+	return startFakeRedis(t, ln, nil)
+}
+
+// TestTLSConfig 测试TLS配置检查
+func TestTLSConfig(t *testing.T) {
+	invalid := []TLSConfig{
+		{Enabled: true, MinVersion: "1.4"},
+		{Enabled: true, CAFile: "ca.pem", CAPEM: "pem"},
+		{Enabled: true, CertFile: "cert.pem"},
+		{Enabled: true, KeyPEM: "key"},
+		{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", CertPEM: "cert"},
+	}
+	for _, tc := range invalid {
+		cfg := RedisConfig{Addr: "127.0.0.1:6379", TLS: tc}
+		if err := cfg.CheckAndSetDefaults(); !IsErrInvalidTLSConfig(err) {
+			t.Errorf("%+v: 预期ErrInvalidTLSConfig错误，实际得到: %v", tc, err)
+		}
+	}
+
+	t.Run("未启用时不检查", func(t *testing.T) {
+		cfg := RedisConfig{Addr: "127.0.0.1:6379", TLS: TLSConfig{MinVersion: "bad"}}
+		if err := cfg.CheckAndSetDefaults(); err != nil {
+			t.Errorf("未启用TLS不应报错: %v", err)
+		}
+	})
+
+	t.Run("生成tls.Config", func(t *testing.T) {
+		tc := TLSConfig{Enabled: true, MinVersion: "1.3"}
+		c, err := tc.Build("cache.local")
+		if err != nil {
+			t.Fatalf("Build失败: %v", err)
+		}
+		if c.MinVersion != tls.VersionTLS13 || c.ServerName != "cache.local" {
+			t.Errorf("tls.Config错误: MinVersion=%x ServerName=%s", c.MinVersion, c.ServerName)
+		}
+	})
+
+	t.Run("无效CA", func(t *testing.T) {
+		tc := TLSConfig{Enabled: true, CAPEM: "not a pem"}
+		if _, err := tc.Build(""); !IsErrInvalidTLSConfig(err) {
+			t.Errorf("预期ErrInvalidTLSConfig错误，实际得到: %v", err)
+		}
+	})
+}
+
+// TestOpenerTLS 测试通过TLS连接Redis
+func TestOpenerTLS(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCert(t, nil, true, 0)
+	server := newTestCert(t, ca, false, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, ca, false, x509.ExtKeyUsageClientAuth)
+
+	t.Run("使用CA校验服务端证书", func(t *testing.T) {
+		srv := newFakeTLSRedis(t, server, nil)
+
+		c, err := opener(ctx, RedisConfig{
+			Addr: srv.Addr(),
+			TLS:  TLSConfig{Enabled: true, CAPEM: ca.certPEM},
+		})
+		if err != nil {
+			t.Fatalf("TLS连接失败: %v", err)
+		}
+		_ = c.Close()
+	})
+
+	t.Run("未知CA连接失败", func(t *testing.T) {
+		srv := newFakeTLSRedis(t, server, nil)
+
+		_, err := opener(ctx, RedisConfig{
+			Addr:       srv.Addr(),
+			MaxRetries: 1,
+			TLS:        TLSConfig{Enabled: true},
+		})
+		if !IsErrPingFailed(err) {
+			t.Errorf("预期ErrPingFailed错误，实际得到: %v", err)
+		}
+	})
+
+	t.Run("跳过证书校验", func(t *testing.T) {
+		srv := newFakeTLSRedis(t, server, nil)
+
+		c, err := opener(ctx, RedisConfig{
+			Addr: srv.Addr(),
+			TLS:  TLSConfig{Enabled: true, InsecureSkipVerify: true},
+		})
+		if err != nil {
+			t.Fatalf("TLS连接失败: %v", err)
+		}
+		_ = c.Close()
+	})
+
+	t.Run("双向认证_证书文件", func(t *testing.T) {
+		srv := newFakeTLSRedis(t, server, ca)
+
+		dir := t.TempDir()
+		files := map[string]string{
+			"ca.pem":     ca.certPEM,
+			"client.pem": client.certPEM,
+			"client.key": client.keyPEM,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatalf("写入证书文件失败: %v", err)
+			}
+		}
+
+		group := New()
+		defer group.Close(ctx)
+
+		_, _ = group.Register(ctx, "secure", RedisConfig{
+			Addr: srv.Addr(),
+			TLS: TLSConfig{
+				Enabled:    true,
+				CAFile:     filepath.Join(dir, "ca.pem"),
+				CertFile:   filepath.Join(dir, "client.pem"),
+				KeyFile:    filepath.Join(dir, "client.key"),
+				ServerName: "localhost",
+			},
+		})
+		c, err := group.Get(ctx, "secure")
+		if err != nil {
+			t.Fatalf("双向认证连接失败: %v", err)
+		}
+		if err := c.Ping(ctx).Err(); err != nil {
+			t.Errorf("Ping失败: %v", err)
+		}
+	})
+
+	t.Run("证书文件不存在", func(t *testing.T) {
+		_, err := opener(ctx, RedisConfig{
+			Addr: "127.0.0.1:6379",
+			TLS:  TLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		})
+		if !IsErrInvalidTLSConfig(err) {
+			t.Errorf("预期ErrInvalidTLSConfig错误，实际得到: %v", err)
+		}
+	})
+}
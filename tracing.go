@@ -0,0 +1,237 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxStatementLen 语句属性的最大长度，超出部分截断
+const maxStatementLen = 256
+
+// 追踪属性名，与OpenTelemetry数据库语义约定保持一致
+const (
+	AttrDBSystem     = "db.system"
+	AttrDBIndex      = "db.redis.database_index"
+	AttrDBOperation  = "db.operation"
+	AttrDBStatement  = "db.statement"
+	AttrServerAddr   = "server.address"
+	AttrGroup        = "mgredis.group"
+	AttrInstanceName = "mgredis.name"
+	AttrPipelineLen  = "mgredis.pipeline.length"
+)
+
+// Attribute 追踪属性
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer 创建追踪span，可适配OpenTelemetry等追踪系统
+type Tracer interface {
+	// Start 创建span，返回的上下文会传给后续的命令执行
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span 一次命令或流水线执行的span
+type Span interface {
+	// RecordError 记录错误，redis.Nil不会被记录
+	RecordError(err error)
+	// End 结束span
+	End()
+}
+
+// WithTracer 为管理器创建的每个客户端安装追踪钩子
+func WithTracer(t Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+// tracingHook 为命令和流水线创建span的go-redis钩子
+type tracingHook struct {
+	tracer Tracer
+	attrs  []Attribute
+}
+
+// newTracingHook 创建追踪钩子，attrs为每个span共有的属性
+func newTracingHook(tracer Tracer, key InstanceKey, cfg RedisConfig) *tracingHook {
+	return &tracingHook{
+		tracer: tracer,
+		attrs: []Attribute{
+			{Key: AttrDBSystem, Value: "redis"},
+			{Key: AttrGroup, Value: key.Group},
+			{Key: AttrInstanceName, Value: key.Name},
+			{Key: AttrDBIndex, Value: cfg.DB},
+			{Key: AttrServerAddr, Value: instanceAddr(cfg)},
+		},
+	}
+}
+
+func (h *tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		attrs := append(h.attrs[:len(h.attrs):len(h.attrs)],
+			Attribute{Key: AttrDBOperation, Value: cmd.Name()},
+			Attribute{Key: AttrDBStatement, Value: sanitizeStatement(cmd.Args())},
+		)
+		ctx, span := h.tracer.Start(ctx, cmd.Name(), attrs...)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordError(span, err)
+		return err
+	}
+}
+
+func (h *tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		statements := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			statements = append(statements, sanitizeStatement(cmd.Args()))
+		}
+		attrs := append(h.attrs[:len(h.attrs):len(h.attrs)],
+			Attribute{Key: AttrDBOperation, Value: pipelineCommand},
+			Attribute{Key: AttrDBStatement, Value: truncateStatement(strings.Join(statements, "\n"))},
+			Attribute{Key: AttrPipelineLen, Value: len(cmds)},
+		)
+		ctx, span := h.tracer.Start(ctx, pipelineCommand, attrs...)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordError(span, err)
+		return err
+	}
+}
+
+// recordError 记录命令错误，redis.Nil表示键不存在，不视为错误
+func recordError(span Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+	}
+}
+
+// sensitiveCommands 参数全部隐藏的命令
+var sensitiveCommands = map[string]bool{
+	"auth":  true,
+	"hello": true,
+}
+
+// sanitizeStatement 生成不含参数值的语句：保留命令名和第一个参数（通常是键），其余参数替换为?
+// 例如 SET user:1 secret EX 60 => "set user:1 ? ? ?"
+func sanitizeStatement(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	name := strings.ToLower(toString(args[0]))
+
+	var b strings.Builder
+	b.WriteString(name)
+	for i := 1; i < len(args); i++ {
+		b.WriteByte(' ')
+		if i == 1 && !sensitiveCommands[name] {
+			b.WriteString(toString(args[i]))
+		} else {
+			b.WriteByte('?')
+		}
+		if b.Len() > maxStatementLen {
+			break
+		}
+	}
+	return truncateStatement(b.String())
+}
+
+// truncateStatement 截断过长的语句
+func truncateStatement(s string) string {
+	if len(s) <= maxStatementLen {
+		return s
+	}
+	return s[:maxStatementLen] + "..."
+}
+
+// toString 将命令参数转换为字符串
+func toString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return "?"
+	}
+}
+
+// InMemoryTracer 将span保存在内存中的Tracer，用于测试
+type InMemoryTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan InMemoryTracer记录的span
+type RecordedSpan struct {
+	Name      string
+	Attrs     map[string]interface{}
+	Err       error
+	StartTime time.Time
+	EndTime   time.Time
+
+	tracer *InMemoryTracer
+}
+
+// NewInMemoryTracer 创建InMemoryTracer
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+// Start 创建span
+func (t *InMemoryTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &RecordedSpan{
+		Name:      name,
+		Attrs:     make(map[string]interface{}, len(attrs)),
+		StartTime: time.Now(),
+		tracer:    t,
+	}
+	for _, attr := range attrs {
+		span.Attrs[attr.Key] = attr.Value
+	}
+	return ctx, span
+}
+
+// Spans 返回已结束的span
+func (t *InMemoryTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(t.spans))
+	for _, span := range t.spans {
+		spans = append(spans, *span)
+	}
+	return spans
+}
+
+// Reset 清空已记录的span
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+// RecordError 记录错误
+func (s *RecordedSpan) RecordError(err error) {
+	s.Err = err
+}
+
+// End 结束span并保存到InMemoryTracer
+func (s *RecordedSpan) End() {
+	s.EndTime = time.Now()
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
@@ -0,0 +1,99 @@
+package mgredis
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestSanitizeStatement 测试语句脱敏
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		args []interface{}
+		want string
+	}{
+		{[]interface{}{"get", "user:1"}, "get user:1"},
+		{[]interface{}{"SET", "user:1", "secret", "ex", 60}, "set user:1 ? ? ?"},
+		{[]interface{}{"auth", "admin", "s3cret"}, "auth ? ?"},
+		{[]interface{}{"hello", 3, "AUTH", "admin", "s3cret"}, "hello ? ? ? ?"},
+		{[]interface{}{"ping"}, "ping"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeStatement(tt.args); got != tt.want {
+			t.Errorf("sanitizeStatement(%v) = %q，预期%q", tt.args, got, tt.want)
+		}
+	}
+
+	long := []interface{}{"del", strings.Repeat("k", 300)}
+	if got := sanitizeStatement(long); len(got) != maxStatementLen+3 || !strings.HasSuffix(got, "...") {
+		t.Errorf("过长的语句应被截断: %d", len(got))
+	}
+}
+
+// TestTracing 测试命令和流水线的span
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if strings.EqualFold(args[0], "GET") {
+			if args[1] == "missing" {
+				return nil, true
+			}
+			return "v", true
+		}
+		return nil, false
+	})
+
+	tracer := NewInMemoryTracer()
+	manager := NewManager(WithTracer(tracer))
+	defer manager.Close(ctx)
+	manager.AddGroup("cache")
+	_, _ = manager.MustGroup("cache").Register(ctx, "main", RedisConfig{Addr: s.Addr(), DB: 2})
+
+	client := manager.MustGroup("cache").MustGet(ctx, "main")
+	tracer.Reset()
+
+	client.Get(ctx, "user:1")
+	client.Get(ctx, "missing")
+	client.Do(ctx, "bogus", "k", "v")
+	pipe := client.Pipeline()
+	pipe.Get(ctx, "a")
+	pipe.Set(ctx, "b", "secret", 0)
+	_, _ = pipe.Exec(ctx)
+
+	spans := tracer.Spans()
+	if len(spans) != 4 {
+		t.Fatalf("预期4个span，实际为%d: %+v", len(spans), spans)
+	}
+
+	get := spans[0]
+	if get.Name != "get" || get.Err != nil || get.EndTime.Before(get.StartTime) {
+		t.Errorf("get span错误: %+v", get)
+	}
+	for key, want := range map[string]interface{}{
+		AttrDBSystem:     "redis",
+		AttrGroup:        "cache",
+		AttrInstanceName: "main",
+		AttrDBIndex:      2,
+		AttrServerAddr:   s.Addr(),
+		AttrDBOperation:  "get",
+		AttrDBStatement:  "get user:1",
+	} {
+		if get.Attrs[key] != want {
+			t.Errorf("属性%s预期%v，实际为%v", key, want, get.Attrs[key])
+		}
+	}
+
+	if spans[1].Err != nil {
+		t.Errorf("redis.Nil不应记录为错误: %v", spans[1].Err)
+	}
+	if spans[2].Err == nil || spans[2].Attrs[AttrDBStatement] != "bogus k ?" {
+		t.Errorf("bogus span错误: %+v", spans[2])
+	}
+
+	pipeline := spans[3]
+	if pipeline.Name != "pipeline" || pipeline.Attrs[AttrPipelineLen] != 2 ||
+		pipeline.Attrs[AttrDBStatement] != "get a\nset b ?" {
+		t.Errorf("pipeline span错误: %+v", pipeline)
+	}
+}
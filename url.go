@@ -0,0 +1,436 @@
+package mgredis
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持的连接URL协议
+const (
+	schemeRedis       = "redis"
+	schemeRedisTLS    = "rediss"
+	schemeUnix        = "unix"
+	schemeSentinel    = "redis+sentinel"
+	schemeSentinelTLS = "rediss+sentinel"
+
+	defaultRedisPort    = "6379"
+	defaultSentinelPort = "26379"
+
+	// redactedPassword 脱敏后的密码占位符
+	redactedPassword = "xxxxx"
+)
+
+// ParseURL 解析Redis连接URL为RedisConfig
+//
+// 支持的格式:
+//   - redis://[[username]:password@]host[:port][/db][?options]
+//   - rediss://[[username]:password@]host[:port][/db][?options] （启用TLS）
+//   - unix://[[username]:password@]/path/to/redis.sock[?db=0&options]
+//   - redis+sentinel://[[username]:password@]host1[:port][,host2[:port]]/master_name[/db][?options]
+//   - rediss+sentinel://... （Sentinel模式并启用TLS）
+//
+// options 为查询参数，参数名与RedisConfig的json标签一致，例如:
+// name、db、pool_size、min_idle_conns、dial_timeout、read_timeout、write_timeout、
+// max_retries、pool_timeout、idle_timeout、slow_threshold、sentinel_password、replica_only。
+// 故障切换字段使用failover_前缀，如failover_addrs（逗号分隔）、failover_fail_after、failover_failback。
+// replicas为逗号分隔的从节点地址列表，replica_selection为从节点选择策略。
+// 熔断器字段使用circuit_breaker_前缀，如circuit_breaker_failure_threshold、circuit_breaker_open_duration。
+// TLS字段使用tls_前缀，如tls_ca_file、tls_cert_file、tls_key_file、tls_server_name、tls_min_version。
+// IPv6主机需用方括号包裹，如redis://[::1]:6379/0。
+// 时间参数支持"3s"格式的时长，或表示秒数的整数。
+func ParseURL(rawURL string) (RedisConfig, error) {
+	var cfg RedisConfig
+	if err := cfg.FromURL(rawURL); err != nil {
+		return RedisConfig{}, err
+	}
+	return cfg, nil
+}
+
+// FromURL 解析Redis连接URL并覆盖到当前配置
+// URL中未出现的字段保持原值，便于在默认配置之上叠加连接串
+func (cfg *RedisConfig) FromURL(rawURL string) error {
+	rawURL, hosts := splitSentinelHosts(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if hosts != "" {
+		u.Host = hosts
+	}
+
+	// 先解析到副本，失败时不修改原配置
+	c := *cfg
+	switch u.Scheme {
+	case schemeRedis, schemeRedisTLS:
+		err = c.parseTCPURL(u)
+	case schemeUnix:
+		err = c.parseUnixURL(u)
+	case schemeSentinel, schemeSentinelTLS:
+		err = c.parseSentinelURL(u)
+	default:
+		err = fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	if err := c.parseQuery(u.Query()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	*cfg = c
+	return nil
+}
+
+// splitSentinelHosts 取出sentinel协议URL中的多个主机地址
+// url.Parse无法处理"host1:26379,host2"这类主机列表，因此先替换为占位主机再解析
+func splitSentinelHosts(rawURL string) (string, string) {
+	for _, scheme := range []string{schemeSentinel, schemeSentinelTLS} {
+		prefix := scheme + "://"
+		if !strings.HasPrefix(rawURL, prefix) {
+			continue
+		}
+
+		rest := rawURL[len(prefix):]
+		end := strings.IndexAny(rest, "/?#")
+		if end < 0 {
+			end = len(rest)
+		}
+		authority := rest[:end]
+		userinfo, hosts := "", authority
+		if at := strings.LastIndex(authority, "@"); at >= 0 {
+			userinfo, hosts = authority[:at+1], authority[at+1:]
+		}
+		if hosts == "" {
+			return rawURL, ""
+		}
+		return prefix + userinfo + "sentinel" + rest[end:], hosts
+	}
+	return rawURL, ""
+}
+
+// parseTCPURL 解析redis/rediss协议
+func (cfg *RedisConfig) parseTCPURL(u *url.URL) error {
+	addr := joinURLHost(u.Host, defaultRedisPort)
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+
+	cfg.Network = "tcp"
+	cfg.Addr = addr
+	cfg.MasterName = ""
+	cfg.SentinelAddrs = nil
+	cfg.TLS.Enabled = u.Scheme == schemeRedisTLS
+	cfg.parseUserinfo(u)
+
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return nil
+	}
+	db, err := strconv.Atoi(path)
+	if err != nil {
+		return fmt.Errorf("invalid database number %q", path)
+	}
+	cfg.DB = db
+	return nil
+}
+
+// parseUnixURL 解析unix协议
+func (cfg *RedisConfig) parseUnixURL(u *url.URL) error {
+	if u.Path == "" {
+		return fmt.Errorf("missing socket path")
+	}
+
+	cfg.Network = "unix"
+	cfg.Addr = u.Path
+	cfg.MasterName = ""
+	cfg.SentinelAddrs = nil
+	cfg.TLS.Enabled = false
+	cfg.parseUserinfo(u)
+	return nil
+}
+
+// parseSentinelURL 解析redis+sentinel/rediss+sentinel协议
+func (cfg *RedisConfig) parseSentinelURL(u *url.URL) error {
+	var addrs []string
+	for _, hostport := range strings.Split(u.Host, ",") {
+		if hostport == "" {
+			continue
+		}
+		addrs = append(addrs, joinURLHost(hostport, defaultSentinelPort))
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("missing sentinel addrs")
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if segments[0] == "" || len(segments) > 2 {
+		return fmt.Errorf("invalid sentinel path %q, want /master_name[/db]", u.Path)
+	}
+
+	cfg.Network = "tcp"
+	cfg.Addr = ""
+	cfg.MasterName = segments[0]
+	cfg.SentinelAddrs = addrs
+	cfg.TLS.Enabled = u.Scheme == schemeSentinelTLS
+	cfg.parseUserinfo(u)
+
+	if len(segments) == 2 {
+		db, err := strconv.Atoi(segments[1])
+		if err != nil {
+			return fmt.Errorf("invalid database number %q", segments[1])
+		}
+		cfg.DB = db
+	}
+	return nil
+}
+
+// joinURLHost 将URL中的主机部分转换为"host:port"地址，未指定端口时使用defaultPort
+// IPv6主机需用方括号包裹，如"[::1]:6379"
+func joinURLHost(hostport, defaultPort string) string {
+	u := url.URL{Host: hostport}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// parseUserinfo 解析用户名和密码
+func (cfg *RedisConfig) parseUserinfo(u *url.URL) {
+	if u.User == nil {
+		return
+	}
+	cfg.Username = u.User.Username()
+	if password, ok := u.User.Password(); ok {
+		cfg.Password = password
+	}
+}
+
+// parseQuery 解析查询参数覆盖
+func (cfg *RedisConfig) parseQuery(q url.Values) error {
+	for key, values := range q {
+		if len(values) == 0 {
+			continue
+		}
+		val := values[len(values)-1]
+
+		var err error
+		switch key {
+		case "name":
+			cfg.Name = val
+		case "db":
+			cfg.DB, err = strconv.Atoi(val)
+		case "password":
+			cfg.Password = val
+		case "pool_size":
+			cfg.PoolSize, err = strconv.Atoi(val)
+		case "min_idle_conns":
+			cfg.MinIdleConns, err = strconv.Atoi(val)
+		case "max_retries":
+			cfg.MaxRetries, err = strconv.Atoi(val)
+		case "dial_timeout":
+			cfg.DialTimeout, err = parseURLDuration(val)
+		case "read_timeout":
+			cfg.ReadTimeout, err = parseURLDuration(val)
+		case "write_timeout":
+			cfg.WriteTimeout, err = parseURLDuration(val)
+		case "pool_timeout":
+			cfg.PoolTimeout, err = parseURLDuration(val)
+		case "idle_timeout":
+			cfg.IdleTimeout, err = parseURLDuration(val)
+		case "slow_threshold":
+			cfg.SlowThreshold, err = parseURLDuration(val)
+		case "sentinel_password":
+			cfg.SentinelPassword = val
+		case "replica_only":
+			cfg.ReplicaOnly, err = strconv.ParseBool(val)
+		case "failover_addrs":
+			cfg.FailoverAddrs = splitList(val)
+		case "failover_fail_after":
+			cfg.Failover.FailAfter, err = strconv.Atoi(val)
+		case "failover_check_interval":
+			cfg.Failover.CheckInterval, err = parseURLDuration(val)
+		case "failover_failback":
+			cfg.Failover.Failback, err = strconv.ParseBool(val)
+		case "replicas":
+			cfg.Replicas = splitList(val)
+		case "replica_selection":
+			cfg.ReplicaSelection = val
+		case "circuit_breaker_failure_threshold":
+			cfg.CircuitBreaker.FailureThreshold, err = strconv.Atoi(val)
+		case "circuit_breaker_open_duration":
+			cfg.CircuitBreaker.OpenDuration, err = parseURLDuration(val)
+		case "circuit_breaker_half_open_probes":
+			cfg.CircuitBreaker.HalfOpenProbes, err = strconv.Atoi(val)
+		case "tls_ca_file":
+			cfg.TLS.CAFile = val
+		case "tls_ca_pem":
+			cfg.TLS.CAPEM = val
+		case "tls_cert_file":
+			cfg.TLS.CertFile = val
+		case "tls_key_file":
+			cfg.TLS.KeyFile = val
+		case "tls_cert_pem":
+			cfg.TLS.CertPEM = val
+		case "tls_key_pem":
+			cfg.TLS.KeyPEM = val
+		case "tls_server_name":
+			cfg.TLS.ServerName = val
+		case "tls_min_version":
+			cfg.TLS.MinVersion = val
+		case "tls_insecure_skip_verify":
+			cfg.TLS.InsecureSkipVerify, err = strconv.ParseBool(val)
+		default:
+			return fmt.Errorf("unknown option %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid option %s=%q: %v", key, val, err)
+		}
+	}
+	return nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空白项
+func splitList(val string) []string {
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseURLDuration 解析时长，支持"3s"格式或表示秒数的整数
+func parseURLDuration(val string) (time.Duration, error) {
+	if n, err := strconv.Atoi(val); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(val)
+}
+
+// URL 将配置编码为连接URL
+// 对经过CheckAndSetDefaults的配置，ParseURL可以将其还原为相同的配置，
+// CredentialsProvider等无法序列化的字段除外
+func (cfg *RedisConfig) URL() string {
+	return cfg.buildURL(false)
+}
+
+// RedactedURL 与URL相同，但密码和TLS私钥会被替换为"xxxxx"，适合输出到日志
+func (cfg *RedisConfig) RedactedURL() string {
+	return cfg.buildURL(true)
+}
+
+func (cfg *RedisConfig) buildURL(redact bool) string {
+	u := &url.URL{}
+	q := url.Values{}
+
+	password := cfg.Password
+	if redact && password != "" {
+		password = redactedPassword
+	}
+	if cfg.Username != "" || password != "" {
+		u.User = url.UserPassword(cfg.Username, password)
+	}
+
+	switch {
+	case cfg.IsSentinel():
+		u.Scheme = schemeSentinel
+		if cfg.TLS.Enabled {
+			u.Scheme = schemeSentinelTLS
+		}
+		u.Host = strings.Join(cfg.SentinelAddrs, ",")
+		u.Path = "/" + cfg.MasterName + "/" + strconv.Itoa(cfg.DB)
+	case cfg.Network == "unix":
+		u.Scheme = schemeUnix
+		u.Path = cfg.Addr
+		q.Set("db", strconv.Itoa(cfg.DB))
+	default:
+		u.Scheme = schemeRedis
+		if cfg.TLS.Enabled {
+			u.Scheme = schemeRedisTLS
+		}
+		u.Host = cfg.Addr
+		u.Path = "/" + strconv.Itoa(cfg.DB)
+	}
+
+	if cfg.Name != "" {
+		q.Set("name", cfg.Name)
+	}
+	setInt := func(key string, n int) {
+		if n != 0 {
+			q.Set(key, strconv.Itoa(n))
+		}
+	}
+	setDuration := func(key string, d time.Duration) {
+		if d != 0 {
+			q.Set(key, d.String())
+		}
+	}
+	setInt("pool_size", cfg.PoolSize)
+	setInt("min_idle_conns", cfg.MinIdleConns)
+	setInt("max_retries", cfg.MaxRetries)
+	setDuration("dial_timeout", cfg.DialTimeout)
+	setDuration("read_timeout", cfg.ReadTimeout)
+	setDuration("write_timeout", cfg.WriteTimeout)
+	setDuration("pool_timeout", cfg.PoolTimeout)
+	setDuration("idle_timeout", cfg.IdleTimeout)
+	setDuration("slow_threshold", cfg.SlowThreshold)
+	if cfg.SentinelPassword != "" {
+		sentinelPassword := cfg.SentinelPassword
+		if redact {
+			sentinelPassword = redactedPassword
+		}
+		q.Set("sentinel_password", sentinelPassword)
+	}
+	if cfg.ReplicaOnly {
+		q.Set("replica_only", "true")
+	}
+	if len(cfg.FailoverAddrs) > 0 {
+		q.Set("failover_addrs", strings.Join(cfg.FailoverAddrs, ","))
+	}
+	setInt("failover_fail_after", cfg.Failover.FailAfter)
+	setDuration("failover_check_interval", cfg.Failover.CheckInterval)
+	if cfg.Failover.Failback {
+		q.Set("failover_failback", "true")
+	}
+	if len(cfg.Replicas) > 0 {
+		q.Set("replicas", strings.Join(cfg.Replicas, ","))
+	}
+	if cfg.ReplicaSelection != "" {
+		q.Set("replica_selection", cfg.ReplicaSelection)
+	}
+	setInt("circuit_breaker_failure_threshold", cfg.CircuitBreaker.FailureThreshold)
+	setDuration("circuit_breaker_open_duration", cfg.CircuitBreaker.OpenDuration)
+	setInt("circuit_breaker_half_open_probes", cfg.CircuitBreaker.HalfOpenProbes)
+	setString := func(key, val string) {
+		if val != "" {
+			q.Set(key, val)
+		}
+	}
+	setString("tls_ca_file", cfg.TLS.CAFile)
+	setString("tls_ca_pem", cfg.TLS.CAPEM)
+	setString("tls_cert_file", cfg.TLS.CertFile)
+	setString("tls_key_file", cfg.TLS.KeyFile)
+	setString("tls_cert_pem", cfg.TLS.CertPEM)
+	if cfg.TLS.KeyPEM != "" {
+		keyPEM := cfg.TLS.KeyPEM
+		if redact {
+			keyPEM = redactedPassword
+		}
+		q.Set("tls_key_pem", keyPEM)
+	}
+	setString("tls_server_name", cfg.TLS.ServerName)
+	setString("tls_min_version", cfg.TLS.MinVersion)
+	if cfg.TLS.InsecureSkipVerify {
+		q.Set("tls_insecure_skip_verify", "true")
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,103 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// DefaultWarmUpConcurrency WarmUp默认的最大并发数
+const DefaultWarmUpConcurrency = 8
+
+// WithWarmUpConcurrency 设置WarmUp同时创建客户端的最大数量
+func WithWarmUpConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.warmUpConcurrency = n
+		}
+	}
+}
+
+// WarmUp 并发创建组内所有已注册的客户端
+//
+// 客户端默认在首次Get时才创建，配置错误的实例要等到第一个请求才会暴露。
+// 服务启动时调用WarmUp可以提前发现问题：每个实例都会执行配置检查和Ping，
+// 返回的错误通过errors.Join合并所有失败实例的*Error（包含组名、实例名和地址），
+// 可通过errors.Is判断具体原因（如ErrNoAddr、ErrPingFailed、ErrAuthFailed）。
+// 创建成功的客户端保留在组中，后续Get直接返回。
+func (g *group) WarmUp(ctx context.Context) error {
+	return warmUp(ctx, g.opts.warmUpConcurrency, []*group{g})
+}
+
+// WarmUp 并发创建所有组内已注册的客户端，所有组共享同一个并发上限
+func (m *manager) WarmUp(ctx context.Context) error {
+	return warmUp(ctx, m.opts.warmUpConcurrency, m.groups())
+}
+
+// warmUp 以最多concurrency个并发创建groups中的所有客户端
+func warmUp(ctx context.Context, concurrency int, groups []*group) error {
+	type target struct {
+		g   *group
+		key InstanceKey
+	}
+	var targets []target
+	for _, g := range groups {
+		for _, name := range g.List() {
+			// 已创建的客户端无需重复创建
+			if _, ok := g.state.opened.Load(name); ok {
+				continue
+			}
+			targets = append(targets, target{g: g, key: InstanceKey{Group: g.name, Name: name}})
+		}
+	}
+	// 按组名和实例名排序，保证错误顺序稳定
+	sort.Slice(targets, func(i, j int) bool {
+		return instanceKeyLess(targets[i].key, targets[j].key)
+	})
+
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		// 上下文取消后不再启动新的创建
+		if err := ctx.Err(); err != nil {
+			errs[i] = instanceError(OpOpen, t.key, "", err)
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = instanceError(OpOpen, t.key, "", ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := t.g.open(ctx, t.key.Name); err != nil {
+				errs[i] = instanceError(OpOpen, t.key, "", err)
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// open 在注册表锁外创建客户端并放入组中，使多个客户端可以并发创建
+func (g *group) open(ctx context.Context, name string) error {
+	cfg, err := g.Config(ctx, name)
+	if err != nil {
+		return notFoundError(OpOpen, g.key(name), err)
+	}
+	client, err := g.openClient(ctx, name, cfg)
+	if err != nil {
+		return err
+	}
+
+	g.state.mu.RLock()
+	defer g.state.mu.RUnlock()
+	return g.adopt(ctx, name, cfg, client)
+}
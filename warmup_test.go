@@ -0,0 +1,139 @@
+package mgredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGroupWarmUp 测试单组预热
+func TestGroupWarmUp(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedisNamed(t, "a")
+
+	group := New()
+	defer group.Close(ctx)
+
+	_, _ = group.Register(ctx, "ok", RedisConfig{Addr: s.Addr()})
+	_, _ = group.Register(ctx, "no-addr", RedisConfig{})
+	_, _ = group.Register(ctx, "down", RedisConfig{Addr: "127.0.0.1:1", MaxRetries: 1})
+
+	err := group.WarmUp(ctx)
+	if err == nil {
+		t.Fatal("预期返回错误")
+	}
+	if !IsErrNoAddr(err) || !IsErrPingFailed(err) {
+		t.Errorf("错误应包含ErrNoAddr和ErrPingFailed: %v", err)
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "mgredis: ping defaultGroup/down (127.0.0.1:1): ") ||
+		!strings.HasPrefix(lines[1], "mgredis: open defaultGroup/no-addr: ") {
+		t.Errorf("错误应按实例排序并包含组名和实例名: %q", lines)
+	}
+
+	// 预热成功的客户端保留在组中
+	before := len(s.Commands())
+	if got := group.MustGet(ctx, "ok").Get(ctx, "k").Val(); got != "a" {
+		t.Errorf("预期a，实际为%q", got)
+	}
+	for _, cmd := range s.Commands()[before:] {
+		if cmd == "PING" {
+			t.Error("预热后Get不应重新创建客户端")
+		}
+	}
+}
+
+// TestManagerWarmUp 测试多组预热及并发上限
+func TestManagerWarmUp(t *testing.T) {
+	ctx := context.Background()
+
+	var inflight, peak int32
+	s := newFakeRedis(t, func(args []string) (interface{}, bool) {
+		if !strings.EqualFold(args[0], "PING") {
+			return nil, false
+		}
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return respSimple("PONG"), true
+	})
+
+	manager := NewManager(WithWarmUpConcurrency(2))
+	defer manager.Close(ctx)
+
+	for _, groupName := range []string{"cache", "session"} {
+		manager.AddGroup(groupName)
+		for i := 0; i < 3; i++ {
+			_, _ = manager.MustGroup(groupName).Register(ctx, fmt.Sprintf("node%d", i), RedisConfig{Addr: s.Addr()})
+		}
+	}
+	_, _ = manager.MustGroup("session").Register(ctx, "broken", RedisConfig{})
+
+	err := manager.WarmUp(ctx)
+	if !IsErrNoAddr(err) || !strings.Contains(err.Error(), "session/broken") {
+		t.Errorf("错误应包含session/broken: %v", err)
+	}
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Errorf("并发数应不超过2，实际为%d", p)
+	}
+
+	if err := manager.MustGroup("cache").WarmUp(ctx); err != nil {
+		t.Errorf("已预热的组不应返回错误: %v", err)
+	}
+}
+
+// TestWarmUpCanceled 测试上下文取消
+func TestWarmUpCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	group := New()
+	defer group.Close(context.Background())
+	_, _ = group.Register(ctx, "cache", RedisConfig{Addr: "127.0.0.1:1"})
+
+	if err := group.WarmUp(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("预期context.Canceled，实际为%v", err)
+	}
+}
+
+// TestGroupAdoptConfigChanged 测试预先创建的客户端配置已变化时，重新创建的客户端同样安装钩子
+func TestGroupAdoptConfigChanged(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeRedisNamed(t, "a")
+
+	g := New().(*group)
+	defer g.Close(ctx)
+
+	cfg := RedisConfig{Addr: s.Addr(), CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 3}}
+	_, _ = g.Register(ctx, "cache", cfg)
+
+	stale := cfg
+	stale.PoolSize = 1
+	client, err := opener(ctx, stale)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+
+	if err := g.adopt(ctx, "cache", stale, client); err != nil {
+		t.Fatalf("adopt失败: %v", err)
+	}
+	current := g.MustGet(ctx, "cache")
+	if current == client {
+		t.Fatal("配置已变化时不应使用预先创建的客户端")
+	}
+	if _, ok := g.state.breakers.Load(current); !ok {
+		t.Error("重新创建的客户端应安装熔断器等钩子")
+	}
+}
@@ -0,0 +1,269 @@
+package mgredis
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval 配置文件的默认轮询间隔
+const DefaultWatchInterval = 5 * time.Second
+
+// InstanceKey 标识管理器中的一个实例
+type InstanceKey struct {
+	// Group 组名
+	Group string
+	// Name 实例名
+	Name string
+}
+
+// String 返回"group/name"格式的字符串
+func (k InstanceKey) String() string {
+	return k.Group + "/" + k.Name
+}
+
+// ReloadEvent 一次配置重新加载的结果
+type ReloadEvent struct {
+	// Path 配置文件路径
+	Path string
+
+	// Time 重新加载的时间
+	Time time.Time
+
+	// Added 新注册的实例
+	Added []InstanceKey
+
+	// Removed 已注销并在排空后关闭的实例
+	Removed []InstanceKey
+
+	// Changed 配置变化并已替换客户端的实例
+	Changed []InstanceKey
+
+	// Rejected 为true表示配置文件无效，未对任何客户端做修改
+	Rejected bool
+
+	// Err 配置文件无效的原因，或应用变更时各实例的错误
+	Err error
+}
+
+// Empty 是否没有任何变化
+func (e ReloadEvent) Empty() bool {
+	return len(e.Added) == 0 && len(e.Removed) == 0 && len(e.Changed) == 0 && e.Err == nil
+}
+
+// WatcherOption 配置文件监听器选项
+type WatcherOption func(*ConfigWatcher)
+
+// WithWatchInterval 设置配置文件的轮询间隔
+func WithWatchInterval(d time.Duration) WatcherOption {
+	return func(w *ConfigWatcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithReloadHandler 设置每次重新加载后的回调，配置文件无效时同样会回调
+func WithReloadHandler(fn func(ReloadEvent)) WatcherOption {
+	return func(w *ConfigWatcher) {
+		w.handler = fn
+	}
+}
+
+// ConfigWatcher 轮询配置文件，并将变化同步到管理器
+//
+// 监听器认为管理器中的组和实例都由配置文件管理：
+// 文件中新增的实例会被注册，删除的实例会被注销并在排空后关闭，
+// 配置变化的实例会通过Reconfigure原子替换。配置文件无效时不会修改任何客户端。
+type ConfigWatcher struct {
+	manager  Manager
+	path     string
+	interval time.Duration
+	handler  func(ReloadEvent)
+
+	mu       sync.Mutex
+	lastHash []byte
+	// retry 上次应用时部分实例失败，内容未变化也需要重新应用
+	retry bool
+}
+
+// NewConfigWatcher 创建配置文件监听器，管理器需由NewManager创建
+func NewConfigWatcher(manager Manager, path string, opts ...WatcherOption) *ConfigWatcher {
+	w := &ConfigWatcher{
+		manager:  manager,
+		path:     path,
+		interval: DefaultWatchInterval,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Watch 立即加载一次配置文件，之后按轮询间隔检查文件内容，变化时重新加载
+// 部分实例应用失败时，即使内容未变化也会在下次轮询时重试；无效的内容只报告一次，直到文件再次变化
+// 阻塞直到ctx取消，通常在单独的goroutine中运行
+func (w *ConfigWatcher) Watch(ctx context.Context) error {
+	w.Reload(ctx)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if w.changed() {
+				w.Reload(ctx)
+			}
+		}
+	}
+}
+
+// changed 文件内容是否与上次加载时不同，或上次应用需要重试
+func (w *ConfigWatcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.retry {
+		return true
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		// 读取失败只报告一次，直到文件重新可读
+		return w.lastHash != nil
+	}
+	sum := sha256.Sum256(data)
+	return !bytes.Equal(sum[:], w.lastHash)
+}
+
+// Reload 立即加载配置文件并同步到管理器，返回本次的变化
+func (w *ConfigWatcher) Reload(ctx context.Context) ReloadEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ev := w.reload(ctx)
+	if w.handler != nil && (!ev.Empty() || ev.Rejected) {
+		w.handler(ev)
+	}
+	return ev
+}
+
+func (w *ConfigWatcher) reload(ctx context.Context) ReloadEvent {
+	ev := ReloadEvent{Path: w.path, Time: time.Now()}
+
+	w.retry = false
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.lastHash = nil
+		ev.Rejected, ev.Err = true, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		return ev
+	}
+	// 内容被解析后即记录摘要，无效的内容不会在每次轮询时重复拒绝
+	sum := sha256.Sum256(data)
+	w.lastHash = sum[:]
+
+	cfg, err := parseManagerConfig(w.path, data)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		ev.Rejected, ev.Err = true, err
+		return ev
+	}
+
+	desired := cfg.Configs()
+	var errs []error
+
+	// 注销已删除的实例，删除空组
+	for _, groupName := range w.manager.ListGroupNames() {
+		g, err := w.manager.Group(groupName)
+		if err != nil {
+			continue
+		}
+		instances, keep := desired[groupName]
+		for _, name := range g.List() {
+			if _, ok := instances[name]; ok {
+				continue
+			}
+			key := InstanceKey{Group: groupName, Name: name}
+			if err := unregisterGracefully(ctx, g, name); err != nil {
+				errs = append(errs, instanceError(OpUnregister, key, "", err))
+				continue
+			}
+			ev.Removed = append(ev.Removed, key)
+		}
+		if !keep {
+			g.Close(ctx)
+		}
+	}
+
+	// 注册新增实例，替换配置变化的实例
+	for _, groupName := range sortedKeys(desired) {
+		w.manager.AddGroup(groupName)
+		g, err := w.manager.Group(groupName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		instances := desired[groupName]
+		for _, name := range sortedKeys(instances) {
+			key := InstanceKey{Group: groupName, Name: name}
+			rc := instances[name]
+
+			current, err := g.Config(ctx, name)
+			if err != nil {
+				if _, err := g.Register(ctx, name, rc); err != nil {
+					errs = append(errs, instanceError(OpOpen, key, instanceAddr(rc), err))
+					continue
+				}
+				ev.Added = append(ev.Added, key)
+				continue
+			}
+
+			if configEqual(current, rc) {
+				continue
+			}
+			if err := g.Reconfigure(ctx, name, rc); err != nil {
+				errs = append(errs, instanceError(OpReconfigure, key, instanceAddr(rc), err))
+				continue
+			}
+			ev.Changed = append(ev.Changed, key)
+		}
+	}
+
+	sortInstanceKeys(ev.Removed)
+	ev.Err = errors.Join(errs...)
+	w.retry = ev.Err != nil
+	return ev
+}
+
+// unregisterGracefully 注销实例，由mgredis创建的组会在排空后关闭旧客户端
+func unregisterGracefully(ctx context.Context, g Group, name string) error {
+	if gg, ok := g.(*group); ok {
+		return gg.unregisterGracefully(ctx, name)
+	}
+	return g.Unregister(ctx, name)
+}
+
+// sortInstanceKeys 按组名和实例名排序
+func sortInstanceKeys(keys []InstanceKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return instanceKeyLess(keys[i], keys[j])
+	})
+}
+
+// instanceKeyLess 按组名和实例名比较
+func instanceKeyLess(a, b InstanceKey) bool {
+	if a.Group != b.Group {
+		return a.Group < b.Group
+	}
+	return a.Name < b.Name
+}
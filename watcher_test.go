@@ -0,0 +1,244 @@
+package mgredis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestConfigWatcherReload 测试配置文件重新加载
+func TestConfigWatcherReload(t *testing.T) {
+	ctx := context.Background()
+	a := newFakeRedisNamed(t, "a")
+	b := newFakeRedisNamed(t, "b")
+
+	path := writeConfigFile(t, "redis.json", fmt.Sprintf(`{
+		"groups": {
+			"cache": {"instances": {"main": {"addr": %q}, "old": {"addr": %q}}},
+			"legacy": {"instances": {"main": {"addr": %q}}}
+		}
+	}`, a.Addr(), a.Addr(), a.Addr()))
+
+	manager := NewManager(WithGracePeriod(0))
+	defer manager.Close(ctx)
+
+	var events []ReloadEvent
+	w := NewConfigWatcher(manager, path, WithReloadHandler(func(ev ReloadEvent) {
+		events = append(events, ev)
+	}))
+
+	ev := w.Reload(ctx)
+	if ev.Err != nil || len(ev.Added) != 3 {
+		t.Fatalf("首次加载结果错误: %+v", ev)
+	}
+	main := manager.MustGroup("cache").MustGet(ctx, "main")
+
+	t.Run("新增删除和修改", func(t *testing.T) {
+		rewrite(t, path, fmt.Sprintf(`{
+			"groups": {
+				"cache": {"instances": {"main": {"addr": %q}, "new": {"addr": %q}}},
+				"session": {"instances": {"main": {"addr": %q}}}
+			}
+		}`, b.Addr(), a.Addr(), a.Addr()))
+
+		ev := w.Reload(ctx)
+		if ev.Err != nil || ev.Rejected {
+			t.Fatalf("重新加载失败: %+v", ev)
+		}
+		assertKeys(t, "Added", ev.Added, "cache/new", "session/main")
+		assertKeys(t, "Removed", ev.Removed, "cache/old", "legacy/main")
+		assertKeys(t, "Changed", ev.Changed, "cache/main")
+
+		names := manager.ListGroupNames()
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, []string{"cache", "session"}) {
+			t.Errorf("组列表错误: %v", names)
+		}
+		client := manager.MustGroup("cache").MustGet(ctx, "main")
+		if client == main || client.Get(ctx, "k").Val() != "b" {
+			t.Error("配置变化的实例应替换为新客户端")
+		}
+	})
+
+	t.Run("内容未变化", func(t *testing.T) {
+		if ev := w.Reload(ctx); !ev.Empty() {
+			t.Errorf("预期没有变化: %+v", ev)
+		}
+	})
+
+	t.Run("无效文件不修改客户端", func(t *testing.T) {
+		before := manager.MustGroup("cache").MustGet(ctx, "main")
+		rewrite(t, path, `{"groups": {"cache": {"instances": {"main": {"db": 1}}}}}`)
+
+		ev := w.Reload(ctx)
+		if !ev.Rejected || !IsErrNoAddr(ev.Err) {
+			t.Fatalf("预期拒绝无效文件: %+v", ev)
+		}
+		if after := manager.MustGroup("cache").MustGet(ctx, "main"); after != before {
+			t.Error("无效文件不应修改客户端")
+		}
+		if len(manager.MustGroup("cache").List()) != 2 {
+			t.Error("无效文件不应注销实例")
+		}
+
+		rewrite(t, path, `{not json`)
+		if ev := w.Reload(ctx); !ev.Rejected || !IsErrInvalidConfig(ev.Err) {
+			t.Errorf("预期拒绝格式错误的文件: %+v", ev)
+		}
+	})
+
+	if len(events) != 4 {
+		t.Errorf("预期回调4次（内容未变化时不回调），实际为%d", len(events))
+	}
+}
+
+// TestConfigWatcherRetry 测试应用失败后内容未变化也会重试
+func TestConfigWatcherRetry(t *testing.T) {
+	ctx := context.Background()
+	a := newFakeRedisNamed(t, "a")
+
+	// 预留一个端口，先关闭监听使其不可达
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	path := writeConfigFile(t, "redis.json", fmt.Sprintf(`{"groups": {"cache": {"instances": {"main": {"addr": %q}}}}}`, a.Addr()))
+	manager := NewManager(WithGracePeriod(0))
+	defer manager.Close(ctx)
+
+	w := NewConfigWatcher(manager, path)
+	if ev := w.Reload(ctx); ev.Err != nil {
+		t.Fatalf("首次加载失败: %+v", ev)
+	}
+	if w.changed() {
+		t.Fatal("加载成功后内容未变化不应重新加载")
+	}
+
+	rewrite(t, path, fmt.Sprintf(`{"groups": {"cache": {"instances": {"main": {"addr": %q, "max_retries": 1}}}}}`, addr))
+	if ev := w.Reload(ctx); ev.Err == nil {
+		t.Fatalf("预期目标不可达时替换失败: %+v", ev)
+	}
+	if !w.changed() {
+		t.Fatal("应用失败后应在下次轮询时重试")
+	}
+
+	// 目标恢复后，未变化的文件应被重新应用
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("无法重新监听%s: %v", addr, err)
+	}
+	startFakeRedis(t, ln, nil)
+
+	ev := w.Reload(ctx)
+	if ev.Err != nil {
+		t.Fatalf("重试失败: %+v", ev)
+	}
+	assertKeys(t, "Changed", ev.Changed, "cache/main")
+	if w.changed() {
+		t.Error("重试成功后内容未变化不应再次重新加载")
+	}
+}
+
+// TestConfigWatcherWatch 测试轮询文件变化
+func TestConfigWatcherWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := newFakeRedisNamed(t, "a")
+
+	path := writeConfigFile(t, "redis.yaml", fmt.Sprintf("groups:\n  cache:\n    instances:\n      main:\n        addr: %s\n", a.Addr()))
+
+	manager := NewManager()
+	defer manager.Close(context.Background())
+
+	events := make(chan ReloadEvent, 10)
+	w := NewConfigWatcher(manager, path,
+		WithWatchInterval(20*time.Millisecond),
+		WithReloadHandler(func(ev ReloadEvent) { events <- ev }),
+	)
+	done := make(chan error, 1)
+	go func() { done <- w.Watch(ctx) }()
+
+	ev := waitEvent(t, events)
+	assertKeys(t, "Added", ev.Added, "cache/main")
+
+	rewrite(t, path, fmt.Sprintf("groups:\n  cache:\n    instances:\n      main:\n        addr: %s\n      extra:\n        addr: %s\n", a.Addr(), a.Addr()))
+	ev = waitEvent(t, events)
+	assertKeys(t, "Added", ev.Added, "cache/extra")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("预期返回context.Canceled，实际为%v", err)
+	}
+}
+
+// TestConfigWatcherRejectOnce 测试未变化的无效文件只报告一次
+func TestConfigWatcherRejectOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := newFakeRedisNamed(t, "a")
+
+	path := writeConfigFile(t, "redis.json", fmt.Sprintf(`{"groups": {"cache": {"instances": {"main": {"addr": %q}}}}}`, a.Addr()))
+	manager := NewManager()
+	defer manager.Close(context.Background())
+
+	events := make(chan ReloadEvent, 100)
+	w := NewConfigWatcher(manager, path,
+		WithWatchInterval(10*time.Millisecond),
+		WithReloadHandler(func(ev ReloadEvent) { events <- ev }),
+	)
+	done := make(chan error, 1)
+	go func() { done <- w.Watch(ctx) }()
+	if ev := waitEvent(t, events); ev.Err != nil {
+		t.Fatalf("首次加载失败: %+v", ev)
+	}
+
+	rewrite(t, path, `{"groups": {"cache": {"instances": {"main": {}}}}}`)
+	if ev := waitEvent(t, events); !ev.Rejected {
+		t.Fatalf("预期拒绝无效文件: %+v", ev)
+	}
+
+	// 等待多个轮询间隔，内容未变化时不应再次报告
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+	if n := len(events); n != 0 {
+		t.Errorf("未变化的无效文件应只报告一次，额外收到%d个事件: %+v", n, <-events)
+	}
+}
+
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+}
+
+func waitEvent(t *testing.T, events <-chan ReloadEvent) ReloadEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待重新加载事件超时")
+		return ReloadEvent{}
+	}
+}
+
+func assertKeys(t *testing.T, field string, keys []InstanceKey, want ...string) {
+	t.Helper()
+	got := make([]string, 0, len(keys))
+	for _, k := range keys {
+		got = append(got, k.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s预期%v，实际为%v", field, want, got)
+	}
+}